@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+	historypb "go.temporal.io/api/history/v1"
+	"go.temporal.io/sdk/client"
+
+	"github.com/aimustaev/service-workflow/internal/activity"
+	"github.com/aimustaev/service-workflow/internal/config"
+	"github.com/aimustaev/service-workflow/internal/engine"
+	"github.com/aimustaev/service-workflow/internal/manager_workflow"
+	"github.com/aimustaev/service-workflow/internal/plugin"
+	"github.com/aimustaev/service-workflow/internal/usecase"
+	"github.com/aimustaev/service-workflow/internal/usertask"
+	"github.com/aimustaev/service-workflow/internal/validation"
+)
+
+// replaycheck проверяет версию конфигурации на экспортированных историях выполнений без сервера Temporal и БД:
+//
+//	replaycheck -name DynamicTicketWorkflow -definition ticket_workflow.json [-id <uuid> -version <v>] history1.json history2.json
+//
+// Если определение использует activity внешних провайдеров, они должны быть доступны по -providers.
+// Выходит с кодом 1, если есть несовместимые выполнения.
+func main() {
+	name := flag.String("name", engine.DynamicWorkflowType, "Config name the definition is activated for")
+	definitionPath := flag.String("definition", "", "Path to the candidate workflow definition JSON")
+	id := flag.String("id", "", "Config version ID, needed when the version content is updated in place")
+	version := flag.String("version", "", "Config version")
+	providerList := flag.String("providers", os.Getenv("ACTIVITY_PROVIDERS"), "Activity providers as name=host:port,...")
+	flag.Parse()
+
+	if *definitionPath == "" || flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: replaycheck -definition <file> [-name <config>] [-id <uuid> -version <v>] <history.json>...")
+		os.Exit(2)
+	}
+
+	content, err := os.ReadFile(*definitionPath)
+	if err != nil {
+		log.Fatalf("Failed to read definition: %v", err)
+	}
+
+	// Без БД subworkflow проверить нельзя, остальное проверяется как при сохранении
+	registry := activity.NewTicketRegistry(activity.NewActivity(nil))
+	usertask.RegisterActivities(registry, nil)
+
+	providers := plugin.Load(context.Background(), config.ParseActivityProviders(*providerList))
+	defer providers.Close()
+	if err := providers.Register(registry); err != nil {
+		log.Fatalf("Failed to register provider activities: %v", err)
+	}
+
+	if errs := validation.NewValidator(registry.Names(), nil, nil).ValidateContent(*name, content); len(errs) > 0 {
+		for _, e := range errs {
+			fmt.Fprintln(os.Stderr, e.Error())
+		}
+		os.Exit(2)
+	}
+
+	candidate := usecase.CompatibilityCandidate{
+		Name: *name,
+		Ref:  manager_workflow.ConfigRef{Version: *version},
+	}
+	if err := json.Unmarshal(content, &candidate.Definition); err != nil {
+		log.Fatalf("Failed to parse definition: %v", err)
+	}
+	if *id != "" {
+		if candidate.Ref.ID, err = uuid.Parse(*id); err != nil {
+			log.Fatalf("Invalid id: %v", err)
+		}
+	}
+
+	var histories []usecase.ExecutionHistory
+	for _, path := range flag.Args() {
+		history, err := readHistory(path)
+		if err != nil {
+			log.Fatalf("Failed to read history %s: %v", path, err)
+		}
+		histories = append(histories, usecase.ExecutionHistory{
+			Source:  filepath.Base(path),
+			History: history,
+		})
+	}
+
+	report, err := usecase.NewCheckCompatibilityUseCase(nil, nil, registry).Check(candidate, histories)
+	if err != nil {
+		log.Fatalf("Compatibility check failed: %v", err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(report)
+
+	if !report.Compatible {
+		os.Exit(1)
+	}
+}
+
+// readHistory читает историю, экспортированную из Temporal UI или temporal workflow show --output json
+func readHistory(path string) (*historypb.History, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return client.HistoryFromJSON(f, client.HistoryJSONOptions{})
+}
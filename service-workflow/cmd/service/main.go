@@ -14,10 +14,14 @@ import (
 	_ "github.com/lib/pq"
 	"go.temporal.io/sdk/client"
 
+	"github.com/aimustaev/service-workflow/internal/activity"
 	"github.com/aimustaev/service-workflow/internal/api"
 	"github.com/aimustaev/service-workflow/internal/config"
 	"github.com/aimustaev/service-workflow/internal/manager_workflow"
+	"github.com/aimustaev/service-workflow/internal/plugin"
 	"github.com/aimustaev/service-workflow/internal/usecase"
+	"github.com/aimustaev/service-workflow/internal/usertask"
+	"github.com/aimustaev/service-workflow/internal/validation"
 )
 
 func main() {
@@ -33,6 +37,13 @@ func main() {
 	// Инициализируем репозиторий конфигураций
 	configRepo := manager_workflow.NewPostgresConfigRepository(db)
 
+	// Календари рабочего времени хранятся рядом с конфигурациями
+	calendarRepo := manager_workflow.NewPostgresCalendarRepository(db)
+	calendarManager := manager_workflow.NewCalendarManager(calendarRepo)
+
+	// Задачи состояний userTask создает воркер, API выдает их исполнителям и принимает результат
+	taskRepo := usertask.NewPostgresRepository(db)
+
 	// Создаем клиент Temporal
 	c, err := client.NewClient(client.Options{
 		HostPort: cfg.GetTemporalAddr(),
@@ -45,22 +56,86 @@ func main() {
 	// Инициализируем use cases
 	startWorkflowUseCase := usecase.NewStartWorkflowUseCase(c)
 	startWorkflowV2UseCase := usecase.NewStartV2WorkflowUseCase(c)
+	getWorkflowStateUseCase := usecase.NewGetWorkflowStateUseCase(c)
 
 	// Создаем HTTP хендлеры
 	startHandler := api.NewStartWorkflowHandler(startWorkflowUseCase)
 	startV2Handler := api.NewStartV2WorkflowHandler(startWorkflowV2UseCase)
+	getWorkflowStateHandler := api.NewGetWorkflowStateHandler(getWorkflowStateUseCase)
 	healthHandler := &api.HealthHandler{}
 
+	// Реестр activity нужен API только для каталога и валидации, сами activity здесь не вызываются
+	activityRegistry := activity.NewTicketRegistry(activity.NewActivity(nil))
+	usertask.RegisterActivities(activityRegistry, taskRepo)
+
+	// Activity внешних провайдеров описываются ими самими, API узнает о них так же, как воркер
+	providers := plugin.Load(context.Background(), cfg.ActivityProviders)
+	defer providers.Close()
+	if err := providers.Register(activityRegistry); err != nil {
+		log.Fatalf("Failed to register provider activities: %v", err)
+	}
+
+	// Менеджер конфигураций нужен валидатору, чтобы проверять subworkflow на существование и циклы
+	configManager := manager_workflow.NewConfigManager(configRepo, time.Minute)
+	configManager.Start(context.Background())
+	configManager.Listen(context.Background(), cfg.GetPostgresDSN())
+	defer configManager.Stop()
+
+	// Определения со schedule запускаются Temporal Schedules, менеджер сверяет их при активации версий.
+	// При старте сверяем все конфигурации, чтобы подхватить изменения, сделанные пока сервис не работал.
+	configManager.SetScheduleClient(c.ScheduleClient())
+	go func() {
+		if err := configManager.ReconcileSchedules(context.Background()); err != nil {
+			log.Printf("Failed to reconcile schedules: %v", err)
+		}
+	}()
+
+	// Валидатор определений workflow знает обо всех activity воркера
+	validator := validation.NewValidator(activityRegistry.Names(), configManager, calendarManager)
+	listActivitiesHandler := api.NewListActivitiesHandler(activityRegistry)
+
+	// Симуляция выполняет определения в тестовом окружении Temporal, activity подменяются заготовками
+	simulateWorkflowUseCase := usecase.NewSimulateWorkflowUseCase(activityRegistry, configManager, calendarManager)
+	simulateConfigHandler := api.NewSimulateConfigHandler(simulateWorkflowUseCase)
+	simulateDefinitionHandler := api.NewSimulateDefinitionHandler(simulateWorkflowUseCase, validator)
+
+	// Проверка совместимости воспроизводит истории запущенных выполнений с активируемой версией
+	checkCompatibilityUseCase := usecase.NewCheckCompatibilityUseCase(c, configManager, activityRegistry)
+
 	// Создаем хендлеры для конфигураций
 	getLatestConfigHandler := api.NewGetLatestConfigHandler(configRepo)
 	getVersionConfigHandler := api.NewGetVersionConfigHandler(configRepo)
-	createConfigHandler := api.NewCreateConfigHandler(configRepo)
-	updateConfigHandler := api.NewUpdateConfigHandler(configRepo)
+	createConfigHandler := api.NewCreateConfigHandler(configRepo, validator, checkCompatibilityUseCase, configManager)
+	updateConfigHandler := api.NewUpdateConfigHandler(configRepo, validator, checkCompatibilityUseCase, configManager)
+	validateConfigHandler := api.NewValidateConfigHandler(validator)
 	listConfigHandler := api.NewListConfigHandler(configRepo)
-	deactivateConfigHandler := api.NewDeactivateConfigHandler(configRepo)
+	deactivateConfigHandler := api.NewDeactivateConfigHandler(configRepo, configManager)
+	activateConfigHandler := api.NewActivateConfigHandler(configRepo, checkCompatibilityUseCase, configManager)
+	rollbackConfigHandler := api.NewRollbackConfigHandler(configRepo, checkCompatibilityUseCase, configManager)
+	checkCompatibilityHandler := api.NewCheckCompatibilityHandler(configRepo, checkCompatibilityUseCase)
 	getSchemaHandler := api.NewGetSchemaHandler(configRepo)
 	listNamesHandler := api.NewListNamesHandler(configRepo)
 	listSummariesHandler := api.NewListSummariesHandler(configRepo)
+	cacheStatsHandler := api.NewCacheStatsHandler(configManager)
+
+	// Создаем хендлеры для календарей
+	listCalendarsHandler := api.NewListCalendarsHandler(calendarRepo)
+	getCalendarHandler := api.NewGetCalendarHandler(calendarRepo)
+	saveCalendarHandler := api.NewSaveCalendarHandler(calendarRepo)
+	deleteCalendarHandler := api.NewDeleteCalendarHandler(calendarRepo, configRepo)
+
+	// Создаем хендлеры для расписаний
+	listSchedulesHandler := api.NewListSchedulesHandler(configManager)
+	pauseScheduleHandler := api.NewPauseScheduleHandler(configManager)
+	unpauseScheduleHandler := api.NewUnpauseScheduleHandler(configManager)
+	triggerScheduleHandler := api.NewTriggerScheduleHandler(configManager)
+	deleteScheduleHandler := api.NewDeleteScheduleHandler(configManager)
+
+	// Создаем хендлеры для задач userTask
+	completeUserTaskUseCase := usecase.NewCompleteUserTaskUseCase(taskRepo, c)
+	listTasksHandler := api.NewListTasksHandler(taskRepo)
+	claimTaskHandler := api.NewClaimTaskHandler(taskRepo)
+	completeTaskHandler := api.NewCompleteTaskHandler(completeUserTaskUseCase)
 
 	// Создаем роутер
 	router := mux.NewRouter()
@@ -68,18 +143,45 @@ func main() {
 	// Регистрируем маршруты для воркфлоу
 	router.HandleFunc("/start", startHandler.Handle).Methods(http.MethodPost)
 	router.HandleFunc("/startV2", startV2Handler.Handle).Methods(http.MethodPost)
+	router.HandleFunc("/workflow/{id}/state", getWorkflowStateHandler.Handle).Methods(http.MethodGet)
 	router.HandleFunc("/health", healthHandler.Handle).Methods(http.MethodGet)
+	router.HandleFunc("/activities", listActivitiesHandler.Handle).Methods(http.MethodGet)
 
 	// Регистрируем маршруты для конфигураций
 	router.HandleFunc("/config/{name}/latest", getLatestConfigHandler.Handle).Methods("GET")
 	router.HandleFunc("/config/{id}/version/{version}", getVersionConfigHandler.Handle).Methods("GET")
 	router.HandleFunc("/config", createConfigHandler.Handle).Methods("POST")
+	router.HandleFunc("/config/validate", validateConfigHandler.Handle).Methods("POST")
+	router.HandleFunc("/config/simulate", simulateDefinitionHandler.Handle).Methods("POST")
+	router.HandleFunc("/config/{name}/simulate", simulateConfigHandler.Handle).Methods("POST")
 	router.HandleFunc("/config/{id}/version/{version}", updateConfigHandler.Handle).Methods("PUT")
 	router.HandleFunc("/config/{id}", listConfigHandler.Handle).Methods("GET")
-	router.HandleFunc("/config/{id}/version/{version}/deactivate", deactivateConfigHandler.Handle).Methods("POST")
+	router.HandleFunc("/config/{name}/deactivate", deactivateConfigHandler.Handle).Methods("POST")
+	router.HandleFunc("/config/{name}/activate/{version}", activateConfigHandler.Handle).Methods("POST")
+	router.HandleFunc("/config/{name}/rollback", rollbackConfigHandler.Handle).Methods("POST")
+	router.HandleFunc("/config/{id}/version/{version}/compatibility", checkCompatibilityHandler.Handle).Methods("POST")
 	router.HandleFunc("/config/{name}/schema", getSchemaHandler.Handle).Methods("GET")
 	router.HandleFunc("/configs", listNamesHandler.Handle).Methods(http.MethodGet)
 	router.HandleFunc("/configs/summaries", listSummariesHandler.Handle).Methods(http.MethodGet)
+	router.HandleFunc("/configs/cache", cacheStatsHandler.Handle).Methods(http.MethodGet)
+
+	// Регистрируем маршруты для календарей
+	router.HandleFunc("/calendars", listCalendarsHandler.Handle).Methods(http.MethodGet)
+	router.HandleFunc("/calendar/{name}", getCalendarHandler.Handle).Methods(http.MethodGet)
+	router.HandleFunc("/calendar/{name}", saveCalendarHandler.Handle).Methods(http.MethodPut)
+	router.HandleFunc("/calendar/{name}", deleteCalendarHandler.Handle).Methods(http.MethodDelete)
+
+	// Регистрируем маршруты для расписаний
+	router.HandleFunc("/schedules", listSchedulesHandler.Handle).Methods(http.MethodGet)
+	router.HandleFunc("/schedule/{name}/pause", pauseScheduleHandler.Handle).Methods(http.MethodPost)
+	router.HandleFunc("/schedule/{name}/unpause", unpauseScheduleHandler.Handle).Methods(http.MethodPost)
+	router.HandleFunc("/schedule/{name}/trigger", triggerScheduleHandler.Handle).Methods(http.MethodPost)
+	router.HandleFunc("/schedule/{name}", deleteScheduleHandler.Handle).Methods(http.MethodDelete)
+
+	// Регистрируем маршруты для задач userTask
+	router.HandleFunc("/tasks", listTasksHandler.Handle).Methods(http.MethodGet)
+	router.HandleFunc("/task/{id}/claim", claimTaskHandler.Handle).Methods(http.MethodPost)
+	router.HandleFunc("/task/{id}/complete", completeTaskHandler.Handle).Methods(http.MethodPost)
 
 	// Создаем HTTP сервер
 	srv := &http.Server{
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"context"
 	"log"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
@@ -9,8 +11,10 @@ import (
 
 	"github.com/aimustaev/service-workflow/internal/config"
 	"github.com/aimustaev/service-workflow/internal/manager_workflow"
+	"github.com/aimustaev/service-workflow/internal/plugin"
 	"github.com/aimustaev/service-workflow/internal/temporal"
 	"github.com/aimustaev/service-workflow/internal/ticket"
+	"github.com/aimustaev/service-workflow/internal/usertask"
 	"github.com/aimustaev/service-workflow/internal/workflow"
 )
 
@@ -30,6 +34,18 @@ func main() {
 	configRepo := manager_workflow.NewPostgresConfigRepository(db)
 	log.Println("Config repository initialized successfully")
 
+	// Config manager reloads configs on Postgres notifications and polls every minute while they are unavailable
+	configManager := manager_workflow.NewConfigManager(configRepo, time.Minute)
+	configManager.Start(context.Background())
+	configManager.Listen(context.Background(), cfg.GetPostgresDSN())
+	defer configManager.Stop()
+
+	// Initialize calendar repository for business-time timers
+	calendarRepo := manager_workflow.NewPostgresCalendarRepository(db)
+
+	// Initialize task repository for userTask states
+	taskRepo := usertask.NewPostgresRepository(db)
+
 	// Create Temporal client configuration
 	temporalConfig := temporal.DefaultConfig()
 	temporalConfig.HostPort = cfg.GetTemporalAddr()
@@ -50,13 +66,19 @@ func main() {
 	}
 	defer ticketClient.Close()
 
+	// Connect to out-of-process activity providers
+	providers := plugin.Load(context.Background(), cfg.ActivityProviders)
+	defer providers.Close()
+
 	// Create worker
 	log.Println("Creating worker...")
 	w := worker.New(temporalClient.GetClient(), "workflow-ticket", worker.Options{})
 
 	// Register workflows
 	log.Println("Registering workflows...")
-	workflow.RegisterWorkflows(w, ticketClient.GetClient(), temporalClient.GetClient(), configRepo)
+	if err := workflow.RegisterWorkflows(w, ticketClient.GetClient(), temporalClient.GetClient(), configManager, calendarRepo, taskRepo, providers); err != nil {
+		log.Fatalln("Unable to register workflows", err)
+	}
 
 	// Start worker
 	log.Println("Starting worker...")
@@ -1,16 +1,20 @@
 package activity
 
 import (
+	"net/http"
+
 	"github.com/aimustaev/service-workflow/internal/generated/proto"
 )
 
 // Client represents a ticket service client
 type Activity struct {
 	ticketClient proto.TicketServiceClient
+	httpClient   *http.Client // Для HttpRequestActivity, таймаут задается на каждый запрос
 }
 
 func NewActivity(ticketClient proto.TicketServiceClient) *Activity {
 	return &Activity{
 		ticketClient: ticketClient,
+		httpClient:   &http.Client{},
 	}
 }
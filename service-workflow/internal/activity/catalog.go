@@ -0,0 +1,138 @@
+package activity
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+var (
+	ticketSchema = json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"id": {"type": "string"},
+			"user": {"type": "string"},
+			"agent": {"type": "string"},
+			"problem_id": {"type": "integer"},
+			"vertical_id": {"type": "integer"},
+			"skill_id": {"type": "integer"},
+			"user_group_id": {"type": "integer"},
+			"channel": {"type": "string"},
+			"status": {"type": "string"},
+			"created_at": {"type": "string"},
+			"updated_at": {"type": "string"}
+		}
+	}`)
+
+	httpRequestSchema = json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"url": {"type": "string"},
+			"method": {"type": "string", "enum": ["GET", "POST", "PUT", "PATCH", "DELETE", "HEAD"]},
+			"headers": {"type": "object", "additionalProperties": {"type": "string"}},
+			"query": {"type": "object", "additionalProperties": {"type": "string"}},
+			"body": {},
+			"timeout": {"type": "string"},
+			"responseMapping": {"type": "object", "additionalProperties": {"type": "string"}}
+		},
+		"required": ["url"]
+	}`)
+
+	messageSchema = json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"ID": {"type": "string"},
+			"From": {"type": "string"},
+			"To": {"type": "string"},
+			"Subject": {"type": "string"},
+			"Body": {"type": "string"},
+			"Tags": {"type": ["array", "null"], "items": {"type": "string"}},
+			"Channel": {"type": "string"}
+		}
+	}`)
+)
+
+// NewTicketRegistry returns the registry of all ticket lifecycle activities.
+// It is the single place where an activity has to be added to become available
+// to the workflow engine, the Temporal worker and the /activities catalog.
+func NewTicketRegistry(a *Activity) *Registry {
+	r := NewRegistry()
+
+	r.MustRegister(Definition{
+		Name:         "CreateTicketActivity",
+		Description:  "Создать тикет в service-tickets",
+		Fn:           a.CreateTicketActivity,
+		InputSchema:  argsSchema(`{"type": "object"}`),
+		OutputSchema: ticketSchema,
+	})
+	r.MustRegister(Definition{
+		Name:        "ProcessMessageActivity",
+		Description: "Обработать текст входящего сообщения",
+		Fn:          a.ProcessMessageActivity,
+		InputSchema: argsSchema(`{"type": "string"}`),
+	})
+	r.MustRegister(Definition{
+		Name:        "WaitActivity",
+		Description: "Подождать указанное количество секунд",
+		Fn:          a.WaitActivity,
+		InputSchema: argsSchema(`{"type": "integer", "minimum": 0}`),
+	})
+	r.MustRegister(Definition{
+		Name:         "ClassifierAcitivity",
+		Description:  "Классификация тикета",
+		Fn:           a.ClassifierAcitivity,
+		InputSchema:  argsSchema(string(ticketSchema)),
+		OutputSchema: ticketSchema,
+	})
+	r.MustRegister(Definition{
+		Name:         "GetOrCreateTicketActivity",
+		Description:  "Создать или получить открытый тикет пользователя",
+		Fn:           a.GetOrCreateTicketActivity,
+		InputSchema:  argsSchema(string(messageSchema)),
+		OutputSchema: ticketSchema,
+	})
+	r.MustRegister(Definition{
+		Name:         "GetTicketByUserActivity",
+		Description:  "Получить открытый тикет пользователя",
+		Fn:           a.GetTicketByUserActivity,
+		InputSchema:  argsSchema(`{"type": "string"}`),
+		OutputSchema: ticketSchema,
+	})
+	r.MustRegister(Definition{
+		Name:         "AddMassageToTicketActivity",
+		Description:  "Добавить сообщение в тикет",
+		Fn:           a.AddMassageToTicketActivity,
+		InputSchema:  argsSchema(string(messageSchema), `{"type": "string"}`),
+		OutputSchema: ticketSchema,
+	})
+	r.MustRegister(Definition{
+		Name:         "SolveTicketAcitivity",
+		Description:  "Перевести тикет в статус resolved",
+		Fn:           a.SolveTicketAcitivity,
+		InputSchema:  argsSchema(string(ticketSchema)),
+		OutputSchema: ticketSchema,
+	})
+	r.MustRegister(Definition{
+		Name:         "MarkTicketErrorActivity",
+		Description:  "Перевести тикет в статус error (компенсация)",
+		Fn:           a.MarkTicketErrorActivity,
+		InputSchema:  argsSchema(string(ticketSchema)),
+		OutputSchema: ticketSchema,
+	})
+
+	r.MustRegister(Definition{
+		Name:         "HttpRequestActivity",
+		Description:  "HTTP-запрос к внешнему сервису: 5xx повторяется, 4xx - нет",
+		Fn:           a.HttpRequestActivity,
+		InputSchema:  argsSchema(string(httpRequestSchema)),
+		OutputSchema: json.RawMessage(`{"type": "object"}`),
+	})
+
+	return r
+}
+
+// argsSchema собирает схему массива позиционных аргументов activity
+func argsSchema(items ...string) json.RawMessage {
+	return json.RawMessage(`{"type": "array", "items": [` + strings.Join(items, ", ") +
+		`], "minItems": ` + strconv.Itoa(len(items)) + `}`)
+}
@@ -0,0 +1,206 @@
+package activity
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+
+	"github.com/aimustaev/service-workflow/internal/valuepath"
+)
+
+// Типы ошибок HttpRequestActivity, их можно указать в catch.errorTypes и retry.nonRetryableErrors
+const (
+	HttpClientErrorType  = "HttpClientError"  // 4xx, кроме 408 и 429 - не повторяется
+	HttpServerErrorType  = "HttpServerError"  // 5xx, 408, 429 - повторяется
+	HttpTimeoutErrorType = "HttpTimeout"      // Нет ответа за timeout - повторяется
+	HttpRequestErrorType = "HttpRequestError" // Некорректный запрос или ответ - не повторяется
+)
+
+const (
+	defaultHttpTimeout  = 30 * time.Second
+	maxHttpResponseBody = 1 << 20 // Ответ попадает в историю workflow, поэтому его размер ограничен
+)
+
+// HttpRequest - вход HttpRequestActivity. Значения полей из state подставляет движок до вызова activity:
+// {"$expr": "$.key"} или {"$template": "https://host/tickets/{{ $.ticket.Id }}"}.
+type HttpRequest struct {
+	URL     string            `json:"url"`
+	Method  string            `json:"method,omitempty"` // По умолчанию GET
+	Headers map[string]string `json:"headers,omitempty"`
+	Query   map[string]string `json:"query,omitempty"`
+	Body    interface{}       `json:"body,omitempty"`    // Строка отправляется как есть, остальное - как JSON
+	Timeout string            `json:"timeout,omitempty"` // Таймаут одного запроса, например "5s", по умолчанию 30s
+	// Ключ результата -> путь в ответе, например "body.customer.id", "body.items[-1].id", "status", "headers.X-Request-Id".
+	// Если не задан, результатом будет весь ответ: status, headers и body.
+	ResponseMapping map[string]string `json:"responseMapping,omitempty"`
+}
+
+// HttpRequestActivity выполняет HTTP-запрос, описанный в определении workflow.
+// Ответ 2xx/3xx - успех, 5xx, 408 и 429 - повторяемая ошибка, остальные 4xx - неповторяемая.
+func (a *Activity) HttpRequestActivity(ctx context.Context, request HttpRequest) (map[string]interface{}, error) {
+	logger := activity.GetLogger(ctx)
+
+	req, err := buildHttpRequest(ctx, request)
+	if err != nil {
+		return nil, temporal.NewNonRetryableApplicationError(err.Error(), HttpRequestErrorType, nil)
+	}
+
+	timeout := defaultHttpTimeout
+	if request.Timeout != "" {
+		if timeout, err = time.ParseDuration(request.Timeout); err != nil {
+			return nil, temporal.NewNonRetryableApplicationError(
+				fmt.Sprintf("invalid timeout %q: %v", request.Timeout, err), HttpRequestErrorType, nil)
+		}
+	}
+	reqCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	logger.Info("Отправка HTTP-запроса", "method", req.Method, "url", req.URL.Redacted())
+
+	resp, err := a.httpClient.Do(req.WithContext(reqCtx))
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, temporal.NewApplicationError(
+				fmt.Sprintf("%s %s: no response within %s", req.Method, req.URL.Redacted(), timeout), HttpTimeoutErrorType)
+		}
+		// Ошибки соединения обычно временные
+		return nil, temporal.NewApplicationError(fmt.Sprintf("%s %s: %v", req.Method, req.URL.Redacted(), err), HttpServerErrorType)
+	}
+	defer resp.Body.Close()
+
+	response, err := readHttpResponse(resp)
+	if err != nil {
+		return nil, temporal.NewNonRetryableApplicationError(err.Error(), HttpRequestErrorType, nil)
+	}
+
+	if err := statusError(req, resp.StatusCode, response); err != nil {
+		logger.Warn("HTTP-запрос завершился ошибкой", "status", resp.StatusCode)
+		return nil, err
+	}
+
+	logger.Info("HTTP-запрос выполнен", "status", resp.StatusCode)
+
+	if len(request.ResponseMapping) == 0 {
+		return response, nil
+	}
+
+	result := make(map[string]interface{}, len(request.ResponseMapping))
+	for key, path := range request.ResponseMapping {
+		value, ok := valuepath.Lookup(response, path)
+		if !ok {
+			return nil, temporal.NewNonRetryableApplicationError(
+				fmt.Sprintf("response has no %s for %s", path, key), HttpRequestErrorType, nil)
+		}
+		result[key] = value
+	}
+	return result, nil
+}
+
+func buildHttpRequest(ctx context.Context, request HttpRequest) (*http.Request, error) {
+	if request.URL == "" {
+		return nil, fmt.Errorf("url is required")
+	}
+
+	u, err := url.Parse(request.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported url scheme %q", u.Scheme)
+	}
+	if len(request.Query) > 0 {
+		query := u.Query()
+		for k, v := range request.Query {
+			query.Set(k, v)
+		}
+		u.RawQuery = query.Encode()
+	}
+
+	method := strings.ToUpper(request.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	var (
+		body        io.Reader
+		contentType string
+	)
+	switch b := request.Body.(type) {
+	case nil:
+	case string:
+		body = strings.NewReader(b)
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode body: %w", err)
+		}
+		body = bytes.NewReader(data)
+		contentType = "application/json"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	for k, v := range request.Headers {
+		req.Header.Set(k, v)
+	}
+	return req, nil
+}
+
+// readHttpResponse приводит ответ к виду {status, headers, body}; JSON-тело декодируется
+func readHttpResponse(resp *http.Response) (map[string]interface{}, error) {
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxHttpResponseBody+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if len(data) > maxHttpResponseBody {
+		return nil, fmt.Errorf("response body exceeds %d bytes", maxHttpResponseBody)
+	}
+
+	headers := make(map[string]interface{}, len(resp.Header))
+	for k := range resp.Header {
+		headers[k] = resp.Header.Get(k)
+	}
+
+	var body interface{}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &body); err != nil {
+			body = string(data)
+		}
+	}
+
+	return map[string]interface{}{
+		"status":  resp.StatusCode,
+		"headers": headers,
+		"body":    body,
+	}, nil
+}
+
+// statusError классифицирует неуспешный статус ответа. Ответ передается в details ошибки.
+func statusError(req *http.Request, status int, response map[string]interface{}) error {
+	if status < 400 {
+		return nil
+	}
+
+	msg := fmt.Sprintf("%s %s: status %d", req.Method, req.URL.Redacted(), status)
+	switch {
+	case status >= 500, status == http.StatusRequestTimeout, status == http.StatusTooManyRequests:
+		return temporal.NewApplicationError(msg, HttpServerErrorType, response)
+	default:
+		return temporal.NewNonRetryableApplicationError(msg, HttpClientErrorType, nil, response)
+	}
+}
@@ -0,0 +1,194 @@
+package activity
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func executeHttpRequest(t *testing.T, request HttpRequest) (map[string]interface{}, error) {
+	t.Helper()
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestActivityEnvironment()
+	a := NewActivity(nil)
+	env.RegisterActivity(a.HttpRequestActivity)
+
+	value, err := env.ExecuteActivity(a.HttpRequestActivity, request)
+	if err != nil {
+		return nil, err
+	}
+	var result map[string]interface{}
+	if err := value.Get(&result); err != nil {
+		t.Fatalf("failed to decode result: %v", err)
+	}
+	return result, nil
+}
+
+// requireApplicationError проверяет тип ошибки и то, будет ли Temporal ее повторять
+func requireApplicationError(t *testing.T, err error, errType string, retryable bool) {
+	t.Helper()
+
+	var appErr *temporal.ApplicationError
+	if !errors.As(err, &appErr) {
+		t.Fatalf("expected application error, got %v", err)
+	}
+	if appErr.Type() != errType {
+		t.Errorf("error type %q, want %q", appErr.Type(), errType)
+	}
+	if appErr.NonRetryable() == retryable {
+		t.Errorf("non-retryable = %v, want %v", appErr.NonRetryable(), !retryable)
+	}
+}
+
+func TestHttpRequestResponseMapping(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/tickets" || r.URL.Query().Get("source") != "workflow" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+		if got := r.Header.Get("Content-Type"); got != "application/json" {
+			t.Errorf("content type %q", got)
+		}
+		w.Header().Set("X-Request-Id", "req-1")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id": "t-1", "items": [{"id": 1}, {"id": 2}]}`))
+	}))
+	defer server.Close()
+
+	result, err := executeHttpRequest(t, HttpRequest{
+		URL:    server.URL + "/tickets",
+		Method: "post",
+		Query:  map[string]string{"source": "workflow"},
+		Body:   map[string]interface{}{"subject": "test"},
+		ResponseMapping: map[string]string{
+			"ticketId":  "body.id",
+			"lastItem":  "body.items[-1].id",
+			"status":    "status",
+			"requestId": "headers.X-Request-Id",
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]interface{}{
+		"ticketId":  "t-1",
+		"lastItem":  float64(2),
+		"status":    float64(http.StatusCreated),
+		"requestId": "req-1",
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("got %#v, want %#v", result, want)
+	}
+}
+
+func TestHttpRequestMissingMappingPath(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id": "t-1"}`))
+	}))
+	defer server.Close()
+
+	_, err := executeHttpRequest(t, HttpRequest{
+		URL:             server.URL,
+		ResponseMapping: map[string]string{"customer": "body.customer.id"},
+	})
+	requireApplicationError(t, err, HttpRequestErrorType, false)
+}
+
+func TestHttpRequestStatusErrors(t *testing.T) {
+	tests := []struct {
+		status    int
+		errType   string
+		retryable bool
+	}{
+		{http.StatusBadRequest, HttpClientErrorType, false},
+		{http.StatusNotFound, HttpClientErrorType, false},
+		{http.StatusRequestTimeout, HttpServerErrorType, true},
+		{http.StatusTooManyRequests, HttpServerErrorType, true},
+		{http.StatusInternalServerError, HttpServerErrorType, true},
+		{http.StatusBadGateway, HttpServerErrorType, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(`{"error": "failed"}`))
+			}))
+			defer server.Close()
+
+			_, err := executeHttpRequest(t, HttpRequest{URL: server.URL})
+			requireApplicationError(t, err, tt.errType, tt.retryable)
+		})
+	}
+}
+
+func TestHttpRequestTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	started := time.Now()
+	_, err := executeHttpRequest(t, HttpRequest{URL: server.URL, Timeout: "50ms"})
+	requireApplicationError(t, err, HttpTimeoutErrorType, true)
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Errorf("request took %s, timeout was not applied", elapsed)
+	}
+}
+
+func TestHttpRequestBodyLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    int
+		wantErr bool
+	}{
+		{"at limit", maxHttpResponseBody, false},
+		{"over limit", maxHttpResponseBody + 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := strings.Repeat("a", tt.size)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(body))
+			}))
+			defer server.Close()
+
+			_, err := executeHttpRequest(t, HttpRequest{
+				URL:             server.URL,
+				ResponseMapping: map[string]string{"status": "status"},
+			})
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			requireApplicationError(t, err, HttpRequestErrorType, false)
+		})
+	}
+}
+
+func TestHttpRequestInvalidRequest(t *testing.T) {
+	for _, request := range []HttpRequest{
+		{},
+		{URL: "ftp://example.com"},
+		{URL: "http://example.com", Timeout: "soon"},
+	} {
+		_, err := executeHttpRequest(t, request)
+		requireApplicationError(t, err, HttpRequestErrorType, false)
+	}
+}
@@ -0,0 +1,40 @@
+package activity
+
+import (
+	"context"
+	"fmt"
+
+	"go.temporal.io/sdk/activity"
+
+	"github.com/aimustaev/service-workflow/internal/generated/proto"
+)
+
+// MarkTicketErrorActivity переводит тикет в статус error, используется как компенсация
+func (a *Activity) MarkTicketErrorActivity(ctx context.Context, request *proto.TicketResponse) (*proto.TicketResponse, error) {
+	logger := activity.GetLogger(ctx)
+
+	if request == nil || request.Id == "" {
+		logger.Info("Тикет не создан, компенсация не требуется")
+		return nil, nil
+	}
+
+	response, err := a.ticketClient.UpdateTicket(ctx, &proto.UpdateTicketRequest{
+		Id:          request.Id,
+		VerticalId:  request.VerticalId,
+		ProblemId:   request.ProblemId,
+		SkillId:     request.SkillId,
+		UserGroupId: request.UserGroupId,
+		User:        request.User,
+		Agent:       request.Agent,
+		Status:      "error",
+		Channel:     request.Channel,
+	})
+	if err != nil {
+		logger.Error("Ошибка при переводе тикета в статус error", "error", err)
+		return nil, fmt.Errorf("failed to mark ticket as error: %w", err)
+	}
+
+	logger.Info("Тикет переведен в статус error", "ticket_id", response.Id)
+
+	return response, nil
+}
@@ -0,0 +1,124 @@
+package activity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Definition describes an activity that can be used in workflow definitions
+type Definition struct {
+	Name         string          // Имя, под которым activity регистрируется в Temporal и указывается в activityName
+	Description  string          // Описание для каталога редактора workflow
+	Fn           interface{}     // Функция activity: func(ctx context.Context, args...) (T, error) или error
+	InputSchema  json.RawMessage // JSON Schema массива позиционных аргументов
+	OutputSchema json.RawMessage // JSON Schema результата
+
+	inputTypes []reflect.Type
+	outputType reflect.Type
+}
+
+// InputTypes returns the argument types of the activity without context.Context
+func (d *Definition) InputTypes() []reflect.Type {
+	return d.inputTypes
+}
+
+// OutputType returns the result type of the activity or nil if it returns only error
+func (d *Definition) OutputType() reflect.Type {
+	return d.outputType
+}
+
+// Registry holds all activities available to the workflow engine
+type Registry struct {
+	activities map[string]*Definition
+}
+
+// NewRegistry creates an empty activity registry
+func NewRegistry() *Registry {
+	return &Registry{
+		activities: make(map[string]*Definition),
+	}
+}
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// Register adds an activity to the registry, input/output types are taken from the function signature
+func (r *Registry) Register(def Definition) error {
+	if def.Name == "" {
+		return fmt.Errorf("activity name is required")
+	}
+	if _, exists := r.activities[def.Name]; exists {
+		return fmt.Errorf("activity %s already registered", def.Name)
+	}
+
+	fnType := reflect.TypeOf(def.Fn)
+	if fnType == nil || fnType.Kind() != reflect.Func {
+		return fmt.Errorf("activity %s: expected function, got %T", def.Name, def.Fn)
+	}
+
+	// Первый аргумент - context.Context, остальные - входные данные activity
+	for i := 0; i < fnType.NumIn(); i++ {
+		in := fnType.In(i)
+		if i == 0 && in == contextType {
+			continue
+		}
+		def.inputTypes = append(def.inputTypes, in)
+	}
+
+	switch fnType.NumOut() {
+	case 1:
+		if fnType.Out(0) != errorType {
+			return fmt.Errorf("activity %s: single result must be error", def.Name)
+		}
+	case 2:
+		if fnType.Out(1) != errorType {
+			return fmt.Errorf("activity %s: second result must be error", def.Name)
+		}
+		def.outputType = fnType.Out(0)
+	default:
+		return fmt.Errorf("activity %s: expected (T, error) or error results", def.Name)
+	}
+
+	r.activities[def.Name] = &def
+	return nil
+}
+
+// MustRegister registers an activity and panics on invalid definition
+func (r *Registry) MustRegister(def Definition) {
+	if err := r.Register(def); err != nil {
+		panic(err)
+	}
+}
+
+// Get returns an activity definition by name
+func (r *Registry) Get(name string) (*Definition, bool) {
+	def, ok := r.activities[name]
+	return def, ok
+}
+
+// List returns all registered activities sorted by name
+func (r *Registry) List() []*Definition {
+	defs := make([]*Definition, 0, len(r.activities))
+	for _, def := range r.activities {
+		defs = append(defs, def)
+	}
+	sort.Slice(defs, func(i, j int) bool {
+		return defs[i].Name < defs[j].Name
+	})
+	return defs
+}
+
+// Names returns the names of all registered activities sorted alphabetically
+func (r *Registry) Names() []string {
+	defs := r.List()
+	names := make([]string, 0, len(defs))
+	for _, def := range defs {
+		names = append(names, def.Name)
+	}
+	return names
+}
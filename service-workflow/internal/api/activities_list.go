@@ -0,0 +1,53 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/aimustaev/service-workflow/internal/activity"
+)
+
+// ActivityInfo описывает activity в каталоге для редактора workflow
+type ActivityInfo struct {
+	Name         string          `json:"name"`
+	Description  string          `json:"description"`
+	Inputs       []string        `json:"inputs"`
+	Output       string          `json:"output,omitempty"`
+	InputSchema  json.RawMessage `json:"inputSchema,omitempty"`
+	OutputSchema json.RawMessage `json:"outputSchema,omitempty"`
+}
+
+type ListActivitiesHandler struct {
+	registry *activity.Registry
+}
+
+func NewListActivitiesHandler(registry *activity.Registry) *ListActivitiesHandler {
+	return &ListActivitiesHandler{
+		registry: registry,
+	}
+}
+
+func (h *ListActivitiesHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	defs := h.registry.List()
+
+	activities := make([]ActivityInfo, 0, len(defs))
+	for _, def := range defs {
+		info := ActivityInfo{
+			Name:         def.Name,
+			Description:  def.Description,
+			Inputs:       make([]string, 0, len(def.InputTypes())),
+			InputSchema:  def.InputSchema,
+			OutputSchema: def.OutputSchema,
+		}
+		for _, in := range def.InputTypes() {
+			info.Inputs = append(info.Inputs, in.String())
+		}
+		if out := def.OutputType(); out != nil {
+			info.Output = out.String()
+		}
+		activities = append(activities, info)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(activities)
+}
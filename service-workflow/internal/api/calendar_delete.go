@@ -0,0 +1,84 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/aimustaev/service-workflow/internal/engine"
+	"github.com/aimustaev/service-workflow/internal/manager_workflow"
+)
+
+type DeleteCalendarHandler struct {
+	repo    manager_workflow.CalendarRepository
+	configs manager_workflow.ConfigVersionRepository
+}
+
+func NewDeleteCalendarHandler(repo manager_workflow.CalendarRepository, configs manager_workflow.ConfigVersionRepository) *DeleteCalendarHandler {
+	return &DeleteCalendarHandler{
+		repo:    repo,
+		configs: configs,
+	}
+}
+
+func (h *DeleteCalendarHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	if name == "" {
+		http.Error(w, "Name parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	// Календарь активной конфигурации понадобится ее таймерам во время выполнения
+	users, err := h.calendarUsers(name)
+	if err != nil {
+		log.Printf("Error checking calendar usage: %v", err)
+		http.Error(w, "Failed to delete calendar", http.StatusInternalServerError)
+		return
+	}
+	if len(users) > 0 {
+		http.Error(w, "Calendar is used by active configs: "+strings.Join(users, ", "), http.StatusConflict)
+		return
+	}
+
+	err = h.repo.Delete(name)
+	if errors.Is(err, manager_workflow.ErrCalendarNotFound) {
+		http.Error(w, "Calendar not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error deleting calendar: %v", err)
+		http.Error(w, "Failed to delete calendar", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// calendarUsers возвращает имена активных конфигураций, состояния которых ссылаются на календарь
+func (h *DeleteCalendarHandler) calendarUsers(calendar string) ([]string, error) {
+	active := true
+	configs, err := h.configs.List(manager_workflow.ConfigVersionFilter{IsActive: &active})
+	if err != nil {
+		return nil, err
+	}
+
+	var users []string
+	for _, config := range configs {
+		var def engine.WorkflowDefinition
+		if err := json.Unmarshal(config.Content, &def); err != nil {
+			log.Printf("Skipping config %s %s while checking calendar usage: %v", config.Name, config.Version, err)
+			continue
+		}
+		for _, name := range engine.CalendarNames(def.States) {
+			if name == calendar {
+				users = append(users, config.Name)
+				break
+			}
+		}
+	}
+	return users, nil
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/aimustaev/service-workflow/internal/manager_workflow"
+)
+
+type GetCalendarHandler struct {
+	repo manager_workflow.CalendarRepository
+}
+
+func NewGetCalendarHandler(repo manager_workflow.CalendarRepository) *GetCalendarHandler {
+	return &GetCalendarHandler{
+		repo: repo,
+	}
+}
+
+func (h *GetCalendarHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	if name == "" {
+		http.Error(w, "Name parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	calendar, err := h.repo.Get(name)
+	if err != nil {
+		log.Printf("Error getting calendar: %v", err)
+		http.Error(w, "Failed to get calendar", http.StatusInternalServerError)
+		return
+	}
+	if calendar == nil {
+		http.Error(w, "Calendar not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(calendar)
+}
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/aimustaev/service-workflow/internal/manager_workflow"
+)
+
+type ListCalendarsHandler struct {
+	repo manager_workflow.CalendarRepository
+}
+
+func NewListCalendarsHandler(repo manager_workflow.CalendarRepository) *ListCalendarsHandler {
+	return &ListCalendarsHandler{
+		repo: repo,
+	}
+}
+
+func (h *ListCalendarsHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	calendars, err := h.repo.List()
+	if err != nil {
+		log.Printf("Error listing calendars: %v", err)
+		http.Error(w, "Failed to list calendars", http.StatusInternalServerError)
+		return
+	}
+	if calendars == nil {
+		calendars = []*manager_workflow.CalendarConfig{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(calendars)
+}
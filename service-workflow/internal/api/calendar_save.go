@@ -0,0 +1,79 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/aimustaev/service-workflow/internal/manager_workflow"
+)
+
+// SaveCalendarRequest представляет запрос на создание или замену календаря
+type SaveCalendarRequest struct {
+	Content   json.RawMessage `json:"content"` // Может быть как JSON объектом, так и строкой
+	CreatedBy string          `json:"created_by"`
+}
+
+// SaveCalendarHandler создает календарь или заменяет его содержимое.
+// Запущенные таймеры не меняются: календарь сохраняется в истории при старте таймера.
+type SaveCalendarHandler struct {
+	repo manager_workflow.CalendarRepository
+}
+
+func NewSaveCalendarHandler(repo manager_workflow.CalendarRepository) *SaveCalendarHandler {
+	return &SaveCalendarHandler{
+		repo: repo,
+	}
+}
+
+func (h *SaveCalendarHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	if name == "" {
+		http.Error(w, "Name parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	var req SaveCalendarRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	contentBytes, err := decodeContent(req.Content)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(contentBytes) == 0 {
+		http.Error(w, "Content is required", http.StatusBadRequest)
+		return
+	}
+
+	calendar := &manager_workflow.CalendarConfig{
+		Name:      name,
+		Content:   contentBytes,
+		CreatedBy: req.CreatedBy,
+	}
+
+	// Проверяем календарь до сохранения
+	parsed, err := manager_workflow.ParseCalendar(calendar)
+	if err != nil {
+		http.Error(w, "Invalid calendar: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := parsed.Validate(); err != nil {
+		http.Error(w, "Invalid calendar: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.Save(calendar); err != nil {
+		log.Printf("Error saving calendar: %v", err)
+		http.Error(w, "Failed to save calendar", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(calendar)
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/aimustaev/service-workflow/internal/manager_workflow"
+	"github.com/aimustaev/service-workflow/internal/usecase"
+)
+
+type ActivateConfigHandler struct {
+	repo          manager_workflow.ConfigVersionRepository
+	compatibility *usecase.CheckCompatibilityUseCase
+	manager       *manager_workflow.ConfigManager
+}
+
+func NewActivateConfigHandler(repo manager_workflow.ConfigVersionRepository, compatibility *usecase.CheckCompatibilityUseCase, manager *manager_workflow.ConfigManager) *ActivateConfigHandler {
+	return &ActivateConfigHandler{
+		repo:          repo,
+		compatibility: compatibility,
+		manager:       manager,
+	}
+}
+
+func (h *ActivateConfigHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+	version := vars["version"]
+	if name == "" || version == "" {
+		http.Error(w, "Name and version parameters are required", http.StatusBadRequest)
+		return
+	}
+
+	configs, err := h.repo.List(manager_workflow.ConfigVersionFilter{Name: &name, Version: &version})
+	if err != nil {
+		log.Printf("Error getting config version: %v", err)
+		http.Error(w, "Failed to get config version", http.StatusInternalServerError)
+		return
+	}
+	if len(configs) == 0 {
+		http.Error(w, "Config not found", http.StatusNotFound)
+		return
+	}
+
+	activateVersion(w, r, h.compatibility, h.manager, configs[0], func() (*manager_workflow.ConfigVersion, error) {
+		return h.repo.Activate(name, version)
+	})
+}
+
+// activateVersion переключает активную версию конфигурации на target и пишет ответ.
+// Перед переключением версия проверяется на запущенных выполнениях, как при сохранении активной версии.
+func activateVersion(w http.ResponseWriter, r *http.Request, compatibility *usecase.CheckCompatibilityUseCase, manager *manager_workflow.ConfigManager, target *manager_workflow.ConfigVersion, activate func() (*manager_workflow.ConfigVersion, error)) {
+	if !allowActivation(w, r, compatibility, target) {
+		return
+	}
+
+	config, err := activate()
+	switch {
+	case errors.Is(err, manager_workflow.ErrConfigNotFound):
+		http.Error(w, "Config not found", http.StatusNotFound)
+		return
+	case errors.Is(err, manager_workflow.ErrActiveVersionChanged):
+		http.Error(w, "Active version was changed concurrently, retry the request", http.StatusConflict)
+		return
+	case err != nil:
+		log.Printf("Error activating config: %v", err)
+		http.Error(w, "Failed to activate config", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("Activated config %s@%s", config.Name, config.Version)
+
+	if !activeVersionChanged(w, r, manager, config.Name) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(config)
+}
+
+// activeVersionChanged обновляет кэш конфигураций этого процесса и сверяет расписание после смены активной версии.
+// Менеджеры воркеров узнают о смене сами: по уведомлению из БД или при очередном опросе.
+// Если сверить расписание не удалось, ответ уже записан и возвращается false.
+func activeVersionChanged(w http.ResponseWriter, r *http.Request, manager *manager_workflow.ConfigManager, name string) bool {
+	// Ошибка кэша не страшна: он обновится по уведомлению или опросу
+	if err := manager.Refresh(name); err != nil {
+		log.Printf("Error refreshing cached config %s: %v", name, err)
+	}
+	return syncSchedule(w, r, manager, name)
+}
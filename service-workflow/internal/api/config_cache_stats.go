@@ -0,0 +1,24 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/aimustaev/service-workflow/internal/manager_workflow"
+)
+
+type CacheStatsHandler struct {
+	manager *manager_workflow.ConfigManager
+}
+
+func NewCacheStatsHandler(manager *manager_workflow.ConfigManager) *CacheStatsHandler {
+	return &CacheStatsHandler{
+		manager: manager,
+	}
+}
+
+// Handle отдает счетчики кэша конфигураций этого процесса; воркеры пишут свои счетчики в лог
+func (h *CacheStatsHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.manager.Stats())
+}
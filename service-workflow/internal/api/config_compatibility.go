@@ -0,0 +1,123 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"github.com/aimustaev/service-workflow/internal/engine"
+	"github.com/aimustaev/service-workflow/internal/manager_workflow"
+	"github.com/aimustaev/service-workflow/internal/usecase"
+)
+
+// ActivationRefusedResponse возвращается, если активацию отклонила проверка совместимости
+type ActivationRefusedResponse struct {
+	Error  string                       `json:"error"`
+	Report *usecase.CompatibilityReport `json:"report"`
+}
+
+// CheckCompatibilityHandler проверяет сохраненную версию на историях запущенных выполнений
+type CheckCompatibilityHandler struct {
+	repo          manager_workflow.ConfigVersionRepository
+	compatibility *usecase.CheckCompatibilityUseCase
+}
+
+func NewCheckCompatibilityHandler(repo manager_workflow.ConfigVersionRepository, compatibility *usecase.CheckCompatibilityUseCase) *CheckCompatibilityHandler {
+	return &CheckCompatibilityHandler{
+		repo:          repo,
+		compatibility: compatibility,
+	}
+}
+
+func (h *CheckCompatibilityHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	idStr := vars["id"]
+	version := vars["version"]
+	if idStr == "" || version == "" {
+		http.Error(w, "ID and version parameters are required", http.StatusBadRequest)
+		return
+	}
+
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	config, err := h.repo.GetByVersion(id, version)
+	if err != nil {
+		log.Printf("Error getting config version: %v", err)
+		http.Error(w, "Failed to get config version", http.StatusInternalServerError)
+		return
+	}
+	if config == nil {
+		http.Error(w, "Config not found", http.StatusNotFound)
+		return
+	}
+
+	candidate, err := compatibilityCandidate(config)
+	if err != nil {
+		http.Error(w, "Invalid workflow definition", http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.compatibility.Execute(r.Context(), candidate)
+	if err != nil {
+		log.Printf("Error checking config compatibility: %v", err)
+		http.Error(w, "Failed to check compatibility", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// allowActivation проверяет активируемую версию на запущенных выполнениях. Если активировать нельзя,
+// ответ уже записан и возвращается false. Параметр force=true пропускает проверку.
+func allowActivation(w http.ResponseWriter, r *http.Request, compatibility *usecase.CheckCompatibilityUseCase, config *manager_workflow.ConfigVersion) bool {
+	if force, _ := strconv.ParseBool(r.URL.Query().Get("force")); force {
+		log.Printf("Compatibility check skipped for %s@%s", config.Name, config.Version)
+		return true
+	}
+
+	candidate, err := compatibilityCandidate(config)
+	if err != nil {
+		http.Error(w, "Invalid workflow definition", http.StatusBadRequest)
+		return false
+	}
+
+	report, err := compatibility.Execute(r.Context(), candidate)
+	if err != nil {
+		log.Printf("Error checking config compatibility: %v", err)
+		http.Error(w, "Failed to check compatibility, use force=true to activate anyway", http.StatusInternalServerError)
+		return false
+	}
+	if report.Compatible {
+		return true
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	json.NewEncoder(w).Encode(ActivationRefusedResponse{
+		Error:  "running executions are incompatible with this version, use force=true to activate anyway",
+		Report: report,
+	})
+	return false
+}
+
+func compatibilityCandidate(config *manager_workflow.ConfigVersion) (usecase.CompatibilityCandidate, error) {
+	var def engine.WorkflowDefinition
+	if err := json.Unmarshal(config.Content, &def); err != nil {
+		return usecase.CompatibilityCandidate{}, err
+	}
+
+	return usecase.CompatibilityCandidate{
+		Name:       config.Name,
+		Ref:        manager_workflow.ConfigRef{ID: config.ID, Version: config.Version},
+		Definition: def,
+	}, nil
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/aimustaev/service-workflow/internal/manager_workflow"
+	"github.com/aimustaev/service-workflow/internal/usecase"
+	"github.com/aimustaev/service-workflow/internal/validation"
 )
 
 // CreateConfigRequest представляет запрос на создание конфигурации
@@ -21,12 +23,18 @@ type CreateConfigRequest struct {
 }
 
 type CreateConfigHandler struct {
-	repo manager_workflow.ConfigVersionRepository
+	repo          manager_workflow.ConfigVersionRepository
+	validator     *validation.Validator
+	compatibility *usecase.CheckCompatibilityUseCase
+	manager       *manager_workflow.ConfigManager
 }
 
-func NewCreateConfigHandler(repo manager_workflow.ConfigVersionRepository) *CreateConfigHandler {
+func NewCreateConfigHandler(repo manager_workflow.ConfigVersionRepository, validator *validation.Validator, compatibility *usecase.CheckCompatibilityUseCase, manager *manager_workflow.ConfigManager) *CreateConfigHandler {
 	return &CreateConfigHandler{
-		repo: repo,
+		repo:          repo,
+		validator:     validator,
+		compatibility: compatibility,
+		manager:       manager,
 	}
 }
 
@@ -43,26 +51,16 @@ func (h *CreateConfigHandler) Handle(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Проверяем, что content является валидным JSON
-	var contentBytes []byte
-	if len(req.Content) > 0 {
-		// Если content - строка, то она должна быть в кавычках
-		if req.Content[0] == '"' && req.Content[len(req.Content)-1] == '"' {
-			// Убираем кавычки и экранирование
-			var contentStr string
-			if err := json.Unmarshal(req.Content, &contentStr); err != nil {
-				http.Error(w, "Invalid content format: string must be properly escaped", http.StatusBadRequest)
-				return
-			}
-			contentBytes = []byte(contentStr)
-		} else {
-			// Проверяем, что это валидный JSON
-			var contentObj interface{}
-			if err := json.Unmarshal(req.Content, &contentObj); err != nil {
-				http.Error(w, "Invalid content format: must be a valid JSON object or string", http.StatusBadRequest)
-				return
-			}
-			contentBytes = req.Content
-		}
+	contentBytes, err := decodeContent(req.Content)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Проверяем определение workflow до сохранения
+	if errs := h.validator.ValidateContent(req.Name, contentBytes); len(errs) > 0 {
+		writeValidationErrors(w, errs)
+		return
 	}
 
 	config := &manager_workflow.ConfigVersion{
@@ -75,12 +73,21 @@ func (h *CreateConfigHandler) Handle(w http.ResponseWriter, r *http.Request) {
 		IsActive:  req.IsActive,
 	}
 
+	// Активная версия сразу подхватывается запущенными выполнениями, проверяем их истории
+	if config.IsActive && !allowActivation(w, r, h.compatibility, config) {
+		return
+	}
+
 	if err := h.repo.Create(config); err != nil {
 		log.Printf("Error creating config: %v", err)
 		http.Error(w, "Failed to create config", http.StatusInternalServerError)
 		return
 	}
 
+	if config.IsActive && !activeVersionChanged(w, r, h.manager, config.Name) {
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(config)
@@ -1,44 +1,53 @@
 package api
 
 import (
+	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 
 	"github.com/aimustaev/service-workflow/internal/manager_workflow"
-	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 )
 
 type DeactivateConfigHandler struct {
-	repo manager_workflow.ConfigVersionRepository
+	repo    manager_workflow.ConfigVersionRepository
+	manager *manager_workflow.ConfigManager
 }
 
-func NewDeactivateConfigHandler(repo manager_workflow.ConfigVersionRepository) *DeactivateConfigHandler {
+func NewDeactivateConfigHandler(repo manager_workflow.ConfigVersionRepository, manager *manager_workflow.ConfigManager) *DeactivateConfigHandler {
 	return &DeactivateConfigHandler{
-		repo: repo,
+		repo:    repo,
+		manager: manager,
 	}
 }
 
+// Handle выключает конфигурацию: снимает активную версию, новые выполнения не запускаются, расписание удаляется.
+// Запущенные выполнения закреплены за своими версиями и продолжают работать.
 func (h *DeactivateConfigHandler) Handle(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	idStr := vars["id"]
-	version := vars["version"]
-	if idStr == "" || version == "" {
-		http.Error(w, "ID and version parameters are required", http.StatusBadRequest)
+	name := mux.Vars(r)["name"]
+	if name == "" {
+		http.Error(w, "Name parameter is required", http.StatusBadRequest)
 		return
 	}
 
-	id, err := uuid.Parse(idStr)
-	if err != nil {
-		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+	config, err := h.repo.Deactivate(name)
+	if errors.Is(err, manager_workflow.ErrConfigNotFound) {
+		http.Error(w, "Config has no active version", http.StatusNotFound)
 		return
 	}
-
-	if err := h.repo.Deactivate(id, version); err != nil {
+	if err != nil {
 		log.Printf("Error deactivating config: %v", err)
 		http.Error(w, "Failed to deactivate config", http.StatusInternalServerError)
 		return
 	}
+	log.Printf("Deactivated config %s@%s", config.Name, config.Version)
+
+	// Без активной версии кэш очищается, а расписание удаляется
+	if !activeVersionChanged(w, r, h.manager, name) {
+		return
+	}
 
-	w.WriteHeader(http.StatusNoContent)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(config)
 }
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/aimustaev/service-workflow/internal/manager_workflow"
+	"github.com/aimustaev/service-workflow/internal/usecase"
+)
+
+type RollbackConfigHandler struct {
+	repo          manager_workflow.ConfigVersionRepository
+	compatibility *usecase.CheckCompatibilityUseCase
+	manager       *manager_workflow.ConfigManager
+}
+
+func NewRollbackConfigHandler(repo manager_workflow.ConfigVersionRepository, compatibility *usecase.CheckCompatibilityUseCase, manager *manager_workflow.ConfigManager) *RollbackConfigHandler {
+	return &RollbackConfigHandler{
+		repo:          repo,
+		compatibility: compatibility,
+		manager:       manager,
+	}
+}
+
+// Handle возвращает активной версию, которая была активна до текущей
+func (h *RollbackConfigHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	if name == "" {
+		http.Error(w, "Name parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	previous, err := h.repo.GetPreviouslyActive(name)
+	if err != nil {
+		log.Printf("Error getting previously active config version: %v", err)
+		http.Error(w, "Failed to get previously active config version", http.StatusInternalServerError)
+		return
+	}
+	if previous == nil {
+		http.Error(w, "Config has no previously active version to roll back to", http.StatusConflict)
+		return
+	}
+
+	activateVersion(w, r, h.compatibility, h.manager, previous, func() (*manager_workflow.ConfigVersion, error) {
+		return h.repo.Rollback(name, previous.Version)
+	})
+}
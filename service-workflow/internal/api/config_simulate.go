@@ -0,0 +1,122 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/aimustaev/service-workflow/internal/engine"
+	"github.com/aimustaev/service-workflow/internal/manager_workflow"
+	"github.com/aimustaev/service-workflow/internal/usecase"
+	"github.com/aimustaev/service-workflow/internal/validation"
+)
+
+// SimulateConfigRequest - сценарий симуляции: входные данные, результаты activity и сигналы
+type SimulateConfigRequest struct {
+	usecase.SimulateWorkflowInput
+}
+
+// SimulateDefinitionRequest - сценарий симуляции для определения, которое еще не сохранено
+type SimulateDefinitionRequest struct {
+	Name    string          `json:"name,omitempty"` // Имя конфигурации, под которым определение будет сохранено; по умолчанию name из content
+	Content json.RawMessage `json:"content"`        // Может быть как JSON объектом, так и строкой
+	usecase.SimulateWorkflowInput
+}
+
+// SimulateConfigHandler симулирует активную версию сохраненной конфигурации
+type SimulateConfigHandler struct {
+	simulateUseCase *usecase.SimulateWorkflowUseCase
+}
+
+func NewSimulateConfigHandler(simulateUseCase *usecase.SimulateWorkflowUseCase) *SimulateConfigHandler {
+	return &SimulateConfigHandler{
+		simulateUseCase: simulateUseCase,
+	}
+}
+
+func (h *SimulateConfigHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+	if name == "" {
+		http.Error(w, "Name parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	var req SimulateConfigRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	output, err := h.simulateUseCase.Execute(name, req.SimulateWorkflowInput)
+	if errors.Is(err, manager_workflow.ErrConfigNotFound) {
+		http.Error(w, "Config not found", http.StatusNotFound)
+		return
+	}
+	writeSimulation(w, output, err)
+}
+
+// SimulateDefinitionHandler проверяет и симулирует определение из тела запроса
+type SimulateDefinitionHandler struct {
+	simulateUseCase *usecase.SimulateWorkflowUseCase
+	validator       *validation.Validator
+}
+
+func NewSimulateDefinitionHandler(simulateUseCase *usecase.SimulateWorkflowUseCase, validator *validation.Validator) *SimulateDefinitionHandler {
+	return &SimulateDefinitionHandler{
+		simulateUseCase: simulateUseCase,
+		validator:       validator,
+	}
+}
+
+func (h *SimulateDefinitionHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	var req SimulateDefinitionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	contentBytes, err := decodeContent(req.Content)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Симулируется только определение, которое можно сохранить
+	if errs := h.validator.ValidateContent(req.Name, contentBytes); len(errs) > 0 {
+		writeValidationErrors(w, errs)
+		return
+	}
+
+	var def engine.WorkflowDefinition
+	if err := json.Unmarshal(contentBytes, &def); err != nil {
+		http.Error(w, "Invalid workflow definition", http.StatusBadRequest)
+		return
+	}
+
+	name := req.Name
+	if name == "" {
+		name = def.Name
+	}
+	output, err := h.simulateUseCase.Simulate(name, def, req.SimulateWorkflowInput)
+	writeSimulation(w, output, err)
+}
+
+// writeSimulation отвечает результатом симуляции; падение workflow - это тоже успешный ответ
+func writeSimulation(w http.ResponseWriter, output *usecase.SimulateWorkflowOutput, err error) {
+	if errors.Is(err, usecase.ErrInvalidSimulation) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("Error simulating workflow: %v", err)
+		http.Error(w, "Failed to simulate workflow", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(output)
+}
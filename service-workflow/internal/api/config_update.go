@@ -2,21 +2,30 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 
 	"github.com/aimustaev/service-workflow/internal/manager_workflow"
+	"github.com/aimustaev/service-workflow/internal/usecase"
+	"github.com/aimustaev/service-workflow/internal/validation"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 )
 
 type UpdateConfigHandler struct {
-	repo manager_workflow.ConfigVersionRepository
+	repo          manager_workflow.ConfigVersionRepository
+	validator     *validation.Validator
+	compatibility *usecase.CheckCompatibilityUseCase
+	manager       *manager_workflow.ConfigManager
 }
 
-func NewUpdateConfigHandler(repo manager_workflow.ConfigVersionRepository) *UpdateConfigHandler {
+func NewUpdateConfigHandler(repo manager_workflow.ConfigVersionRepository, validator *validation.Validator, compatibility *usecase.CheckCompatibilityUseCase, manager *manager_workflow.ConfigManager) *UpdateConfigHandler {
 	return &UpdateConfigHandler{
-		repo: repo,
+		repo:          repo,
+		validator:     validator,
+		compatibility: compatibility,
+		manager:       manager,
 	}
 }
 
@@ -44,12 +53,49 @@ func (h *UpdateConfigHandler) Handle(w http.ResponseWriter, r *http.Request) {
 	config.ID = id
 	config.Version = version
 
-	if err := h.repo.Update(&config); err != nil {
+	existing, err := h.repo.GetByVersion(id, version)
+	if err != nil {
+		log.Printf("Error getting config version: %v", err)
+		http.Error(w, "Failed to get config version", http.StatusInternalServerError)
+		return
+	}
+	if existing == nil {
+		http.Error(w, "Config not found", http.StatusNotFound)
+		return
+	}
+	config.Name = existing.Name
+
+	// Проверяем определение workflow до сохранения
+	if errs := h.validator.ValidateContent(config.Name, config.Content); len(errs) > 0 {
+		writeValidationErrors(w, errs)
+		return
+	}
+
+	// Активная версия сразу подхватывается запущенными выполнениями, проверяем их истории
+	if config.IsActive && !allowActivation(w, r, h.compatibility, &config) {
+		return
+	}
+
+	err = h.repo.Update(&config)
+	if errors.Is(err, manager_workflow.ErrVersionImmutable) {
+		http.Error(w, "Content of a stored version cannot be changed, create a new version", http.StatusConflict)
+		return
+	}
+	if errors.Is(err, manager_workflow.ErrActiveVersionRequired) {
+		http.Error(w, "Active version cannot be deactivated, activate or roll back to another version or deactivate the config instead", http.StatusConflict)
+		return
+	}
+	if err != nil {
 		log.Printf("Error updating config: %v", err)
 		http.Error(w, "Failed to update config", http.StatusInternalServerError)
 		return
 	}
 
+	// Версия могла стать активной
+	if config.IsActive && !activeVersionChanged(w, r, h.manager, config.Name) {
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(config)
 }
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/aimustaev/service-workflow/internal/validation"
+)
+
+// ValidateConfigRequest представляет запрос на проверку определения workflow
+type ValidateConfigRequest struct {
+	Name    string          `json:"name,omitempty"` // Имя конфигурации, под которым определение будет сохранено; по умолчанию name из content
+	Content json.RawMessage `json:"content"`        // Может быть как JSON объектом, так и строкой
+}
+
+// ValidateConfigResponse содержит результат проверки определения workflow
+type ValidateConfigResponse struct {
+	Valid  bool               `json:"valid"`
+	Errors []validation.Error `json:"errors"`
+}
+
+type ValidateConfigHandler struct {
+	validator *validation.Validator
+}
+
+func NewValidateConfigHandler(validator *validation.Validator) *ValidateConfigHandler {
+	return &ValidateConfigHandler{
+		validator: validator,
+	}
+}
+
+func (h *ValidateConfigHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	var req ValidateConfigRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	contentBytes, err := decodeContent(req.Content)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	errs := h.validator.ValidateContent(req.Name, contentBytes)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ValidateConfigResponse{
+		Valid:  len(errs) == 0,
+		Errors: errs,
+	})
+}
+
+// decodeContent приводит content к байтам JSON: content может быть JSON объектом или строкой с JSON
+func decodeContent(content json.RawMessage) ([]byte, error) {
+	if len(content) == 0 {
+		return nil, nil
+	}
+
+	// Если content - строка, то она должна быть в кавычках
+	if content[0] == '"' && content[len(content)-1] == '"' {
+		// Убираем кавычки и экранирование
+		var contentStr string
+		if err := json.Unmarshal(content, &contentStr); err != nil {
+			return nil, errors.New("Invalid content format: string must be properly escaped")
+		}
+		return []byte(contentStr), nil
+	}
+
+	// Проверяем, что это валидный JSON
+	var contentObj interface{}
+	if err := json.Unmarshal(content, &contentObj); err != nil {
+		return nil, errors.New("Invalid content format: must be a valid JSON object or string")
+	}
+	return content, nil
+}
+
+// writeValidationErrors отвечает 400 со списком ошибок валидации определения
+func writeValidationErrors(w http.ResponseWriter, errs []validation.Error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(ValidateConfigResponse{
+		Valid:  false,
+		Errors: errs,
+	})
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/aimustaev/service-workflow/internal/manager_workflow"
+)
+
+type DeleteScheduleHandler struct {
+	schedules *manager_workflow.ConfigManager
+}
+
+func NewDeleteScheduleHandler(schedules *manager_workflow.ConfigManager) *DeleteScheduleHandler {
+	return &DeleteScheduleHandler{
+		schedules: schedules,
+	}
+}
+
+// Handle удаляет расписание; оно будет создано снова при следующей активации версии со schedule
+func (h *DeleteScheduleHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	if name == "" {
+		http.Error(w, "Name parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.schedules.DeleteSchedule(r.Context(), name); err != nil {
+		writeScheduleError(w, err, "delete")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/aimustaev/service-workflow/internal/manager_workflow"
+)
+
+type ListSchedulesHandler struct {
+	schedules *manager_workflow.ConfigManager
+}
+
+func NewListSchedulesHandler(schedules *manager_workflow.ConfigManager) *ListSchedulesHandler {
+	return &ListSchedulesHandler{
+		schedules: schedules,
+	}
+}
+
+func (h *ListSchedulesHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	schedules, err := h.schedules.ListSchedules(r.Context())
+	if errors.Is(err, manager_workflow.ErrSchedulesDisabled) {
+		http.Error(w, "Schedules are not configured", http.StatusNotImplemented)
+		return
+	}
+	if err != nil {
+		log.Printf("Error listing schedules: %v", err)
+		http.Error(w, "Failed to list schedules", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schedules)
+}
+
+// syncSchedule сверяет расписание конфигурации после изменения ее активной версии.
+// Конфигурация к этому моменту уже сохранена; если сверить не удалось, ответ уже записан и возвращается false.
+func syncSchedule(w http.ResponseWriter, r *http.Request, schedules *manager_workflow.ConfigManager, name string) bool {
+	if err := schedules.ReconcileSchedule(r.Context(), name); err != nil {
+		log.Printf("Error syncing schedule of %s: %v", name, err)
+		http.Error(w, "Config saved, but failed to sync its schedule", http.StatusInternalServerError)
+		return false
+	}
+	return true
+}
+
+// writeScheduleError отвечает на ошибку операции с расписанием
+func writeScheduleError(w http.ResponseWriter, err error, action string) {
+	switch {
+	case errors.Is(err, manager_workflow.ErrScheduleNotFound):
+		http.Error(w, "Schedule not found", http.StatusNotFound)
+	case errors.Is(err, manager_workflow.ErrSchedulesDisabled):
+		http.Error(w, "Schedules are not configured", http.StatusNotImplemented)
+	default:
+		log.Printf("Error trying to %s schedule: %v", action, err)
+		http.Error(w, "Failed to "+action+" schedule", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/aimustaev/service-workflow/internal/manager_workflow"
+)
+
+// PauseScheduleRequest представляет запрос на паузу или возобновление расписания, тело необязательно
+type PauseScheduleRequest struct {
+	Note string `json:"note,omitempty"`
+}
+
+type PauseScheduleHandler struct {
+	schedules *manager_workflow.ConfigManager
+}
+
+func NewPauseScheduleHandler(schedules *manager_workflow.ConfigManager) *PauseScheduleHandler {
+	return &PauseScheduleHandler{
+		schedules: schedules,
+	}
+}
+
+func (h *PauseScheduleHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	if name == "" {
+		http.Error(w, "Name parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	var req PauseScheduleRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := h.schedules.PauseSchedule(r.Context(), name, req.Note); err != nil {
+		writeScheduleError(w, err, "pause")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
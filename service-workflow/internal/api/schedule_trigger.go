@@ -0,0 +1,34 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/aimustaev/service-workflow/internal/manager_workflow"
+)
+
+type TriggerScheduleHandler struct {
+	schedules *manager_workflow.ConfigManager
+}
+
+func NewTriggerScheduleHandler(schedules *manager_workflow.ConfigManager) *TriggerScheduleHandler {
+	return &TriggerScheduleHandler{
+		schedules: schedules,
+	}
+}
+
+func (h *TriggerScheduleHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	if name == "" {
+		http.Error(w, "Name parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.schedules.TriggerSchedule(r.Context(), name); err != nil {
+		writeScheduleError(w, err, "trigger")
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
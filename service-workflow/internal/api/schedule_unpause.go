@@ -0,0 +1,43 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/aimustaev/service-workflow/internal/manager_workflow"
+)
+
+type UnpauseScheduleHandler struct {
+	schedules *manager_workflow.ConfigManager
+}
+
+func NewUnpauseScheduleHandler(schedules *manager_workflow.ConfigManager) *UnpauseScheduleHandler {
+	return &UnpauseScheduleHandler{
+		schedules: schedules,
+	}
+}
+
+func (h *UnpauseScheduleHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	if name == "" {
+		http.Error(w, "Name parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	var req PauseScheduleRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := h.schedules.UnpauseSchedule(r.Context(), name, req.Note); err != nil {
+		writeScheduleError(w, err, "unpause")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
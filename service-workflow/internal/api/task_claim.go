@@ -0,0 +1,68 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"github.com/aimustaev/service-workflow/internal/usertask"
+)
+
+// ClaimTaskRequest представляет запрос на взятие задачи в работу
+type ClaimTaskRequest struct {
+	User string `json:"user"`
+}
+
+type ClaimTaskHandler struct {
+	repo usertask.Repository
+}
+
+func NewClaimTaskHandler(repo usertask.Repository) *ClaimTaskHandler {
+	return &ClaimTaskHandler{
+		repo: repo,
+	}
+}
+
+func (h *ClaimTaskHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	var req ClaimTaskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.User == "" {
+		http.Error(w, "User is required", http.StatusBadRequest)
+		return
+	}
+
+	task, err := h.repo.Claim(id, req.User)
+	if err != nil {
+		writeTaskError(w, err, "claim")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(task)
+}
+
+// writeTaskError отвечает на ошибку операции с задачей
+func writeTaskError(w http.ResponseWriter, err error, action string) {
+	switch {
+	case errors.Is(err, usertask.ErrTaskNotFound):
+		http.Error(w, "Task not found", http.StatusNotFound)
+	case errors.Is(err, usertask.ErrTaskNotAvailable):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		log.Printf("Error trying to %s task: %v", action, err)
+		http.Error(w, "Failed to "+action+" task", http.StatusInternalServerError)
+	}
+}
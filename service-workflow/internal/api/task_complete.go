@@ -0,0 +1,79 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"github.com/aimustaev/service-workflow/internal/usecase"
+)
+
+// CompleteTaskRequest представляет запрос на выполнение задачи; payload проверяется по formSchema
+type CompleteTaskRequest struct {
+	User    string          `json:"user"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// TaskPayloadErrorResponse - ответ 400, если payload не соответствует formSchema задачи
+type TaskPayloadErrorResponse struct {
+	Error    string   `json:"error"`
+	Problems []string `json:"problems"`
+}
+
+type CompleteTaskHandler struct {
+	completeUserTaskUseCase *usecase.CompleteUserTaskUseCase
+}
+
+func NewCompleteTaskHandler(completeUserTaskUseCase *usecase.CompleteUserTaskUseCase) *CompleteTaskHandler {
+	return &CompleteTaskHandler{
+		completeUserTaskUseCase: completeUserTaskUseCase,
+	}
+}
+
+func (h *CompleteTaskHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	var req CompleteTaskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.User == "" {
+		http.Error(w, "User is required", http.StatusBadRequest)
+		return
+	}
+
+	task, err := h.completeUserTaskUseCase.Execute(r.Context(), usecase.CompleteUserTaskInput{
+		TaskID:  id,
+		User:    req.User,
+		Payload: req.Payload,
+	})
+
+	var payloadErr *usecase.TaskPayloadError
+	switch {
+	case errors.As(err, &payloadErr):
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(TaskPayloadErrorResponse{
+			Error:    "payload does not match form schema",
+			Problems: payloadErr.Problems,
+		})
+		return
+	case errors.Is(err, usecase.ErrTaskWorkflowClosed):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		writeTaskError(w, err, "complete")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(task)
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/aimustaev/service-workflow/internal/usertask"
+)
+
+type ListTasksHandler struct {
+	repo usertask.Repository
+}
+
+func NewListTasksHandler(repo usertask.Repository) *ListTasksHandler {
+	return &ListTasksHandler{
+		repo: repo,
+	}
+}
+
+// Handle возвращает открытые задачи; фильтры assignee, group и ticket_id передаются в query
+func (h *ListTasksHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	tasks, err := h.repo.ListOpen(usertask.Filter{
+		Assignee: query.Get("assignee"),
+		Group:    query.Get("group"),
+		TicketID: query.Get("ticket_id"),
+	})
+	if err != nil {
+		log.Printf("Error listing tasks: %v", err)
+		http.Error(w, "Failed to list tasks", http.StatusInternalServerError)
+		return
+	}
+	if tasks == nil {
+		tasks = []*usertask.Task{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tasks)
+}
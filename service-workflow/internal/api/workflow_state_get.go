@@ -0,0 +1,47 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.temporal.io/api/serviceerror"
+
+	"github.com/aimustaev/service-workflow/internal/usecase"
+)
+
+type GetWorkflowStateHandler struct {
+	getWorkflowStateUseCase *usecase.GetWorkflowStateUseCase
+}
+
+func NewGetWorkflowStateHandler(getWorkflowStateUseCase *usecase.GetWorkflowStateUseCase) *GetWorkflowStateHandler {
+	return &GetWorkflowStateHandler{
+		getWorkflowStateUseCase: getWorkflowStateUseCase,
+	}
+}
+
+func (h *GetWorkflowStateHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+	if id == "" {
+		http.Error(w, "ID parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	output, err := h.getWorkflowStateUseCase.Execute(r.Context(), id)
+	if err != nil {
+		var notFound *serviceerror.NotFound
+		if errors.As(err, &notFound) {
+			http.Error(w, "Workflow not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error getting workflow state: %v", err)
+		http.Error(w, "Failed to get workflow state", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(output)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -15,6 +16,8 @@ type Config struct {
 	Kafka    KafkaConfig
 	Ticket   TicketConfig
 	Postgres PostgresConfig
+	// Внешние провайдеры activity, подключаемые по gRPC
+	ActivityProviders []ActivityProviderConfig
 }
 
 // HTTPConfig holds HTTP server configuration
@@ -53,6 +56,12 @@ type PostgresConfig struct {
 	SSLMode  string
 }
 
+// ActivityProviderConfig holds the endpoint of an out-of-process activity provider
+type ActivityProviderConfig struct {
+	Name string // Пространство имен activity провайдера: "<name>.<activity>"
+	Addr string
+}
+
 // Load loads configuration from environment variables
 func Load() *Config {
 	// Load .env file if it exists
@@ -87,6 +96,7 @@ func Load() *Config {
 			Database: getEnv("POSTGRES_DB", "service_tickets"),
 			SSLMode:  getEnv("POSTGRES_SSLMODE", "disable"),
 		},
+		ActivityProviders: ParseActivityProviders(getEnv("ACTIVITY_PROVIDERS", "")),
 	}
 }
 
@@ -118,6 +128,27 @@ func (c *Config) GetPostgresDSN() string {
 	)
 }
 
+// ParseActivityProviders parses a provider list like "billing=billing-plugin:50051,crm=crm-plugin:50051"
+func ParseActivityProviders(value string) []ActivityProviderConfig {
+	var providers []ActivityProviderConfig
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, addr, ok := strings.Cut(item, "=")
+		if !ok {
+			log.Printf("Invalid activity provider %q, expected name=host:port", item)
+			continue
+		}
+		providers = append(providers, ActivityProviderConfig{
+			Name: strings.TrimSpace(name),
+			Addr: strings.TrimSpace(addr),
+		})
+	}
+	return providers
+}
+
 // getEnv gets an environment variable or returns a default value
 func getEnv(key, defaultValue string) string {
 	value := os.Getenv(key)
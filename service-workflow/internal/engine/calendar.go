@@ -0,0 +1,295 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	_ "time/tzdata" // Часовые пояса календарей не должны зависеть от образа воркера
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// ErrCalendarNotFound is returned by CalendarSource when the calendar does not exist
+var ErrCalendarNotFound = errors.New("calendar not found")
+
+// calendarSearchDays ограничивает поиск рабочего времени, чтобы календарь без рабочих часов не зациклил workflow
+const calendarSearchDays = 5 * 366
+
+const holidayLayout = "2006-01-02"
+
+// Calendar describes working hours used by business-time timers
+type Calendar struct {
+	Name         string                     `json:"name"`
+	TimeZone     string                     `json:"timeZone"`           // IANA, например "Europe/Moscow"
+	WorkingHours map[string][]WorkingPeriod `json:"workingHours"`       // День недели ("monday") -> рабочие интервалы
+	Holidays     []string                   `json:"holidays,omitempty"` // Нерабочие дни, "2006-01-02"
+}
+
+// WorkingPeriod is a working interval within a day, "09:00"-"18:00"
+type WorkingPeriod struct {
+	Start string `json:"start"`
+	End   string `json:"end"` // "24:00" - до конца дня
+}
+
+// CalendarSource returns calendars referenced by timer and waitForSignal states.
+// It is implemented by manager_workflow.CalendarManager and returns ErrCalendarNotFound for unknown names.
+type CalendarSource interface {
+	GetCalendar(name string) (Calendar, error)
+}
+
+// WithCalendars returns a copy of the engine that resolves business-time durations with calendars
+func (e *WorkflowEngine) WithCalendars(calendars CalendarSource) *WorkflowEngine {
+	c := *e
+	c.calendars = calendars
+	return &c
+}
+
+// period - рабочий интервал в минутах от начала дня
+type period struct {
+	start, end int
+}
+
+// schedule - разобранный календарь
+type schedule struct {
+	location *time.Location
+	days     [7][]period
+	holidays map[string]struct{}
+}
+
+// Validate checks the calendar and returns the first problem found
+func (c Calendar) Validate() error {
+	_, err := c.schedule()
+	return err
+}
+
+// Deadline returns the moment when duration of working time has passed since start.
+// Time outside working hours and on holidays is not counted.
+func (c Calendar) Deadline(start time.Time, duration time.Duration) (time.Time, error) {
+	s, err := c.schedule()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if duration < 0 {
+		return time.Time{}, fmt.Errorf("negative duration %s", duration)
+	}
+
+	current := start.In(s.location)
+	remaining := duration
+	day := time.Date(current.Year(), current.Month(), current.Day(), 0, 0, 0, 0, s.location)
+
+	for i := 0; i < calendarSearchDays; i++ {
+		if _, holiday := s.holidays[day.Format(holidayLayout)]; !holiday {
+			for _, p := range s.days[day.Weekday()] {
+				// time.Date сам нормализует время, пропущенное при переходе на летнее время
+				from := time.Date(day.Year(), day.Month(), day.Day(), 0, p.start, 0, 0, s.location)
+				to := time.Date(day.Year(), day.Month(), day.Day(), 0, p.end, 0, 0, s.location)
+				if from.Before(current) {
+					from = current
+				}
+				if !from.Before(to) {
+					continue
+				}
+
+				available := to.Sub(from)
+				if remaining <= available {
+					return from.Add(remaining), nil
+				}
+				remaining -= available
+			}
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+
+	return time.Time{}, fmt.Errorf("calendar %s has no working time within %d days", c.Name, calendarSearchDays)
+}
+
+func (c Calendar) schedule() (*schedule, error) {
+	if c.TimeZone == "" {
+		return nil, fmt.Errorf("timeZone is required")
+	}
+	location, err := time.LoadLocation(c.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timeZone %q: %w", c.TimeZone, err)
+	}
+
+	s := &schedule{
+		location: location,
+		holidays: make(map[string]struct{}, len(c.Holidays)),
+	}
+
+	working := false
+	for name, periods := range c.WorkingHours {
+		weekday, ok := parseWeekday(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown weekday %q", name)
+		}
+
+		for _, wp := range periods {
+			start, err := parseClock(wp.Start)
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid start: %w", name, err)
+			}
+			end, err := parseClock(wp.End)
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid end: %w", name, err)
+			}
+			if start >= end {
+				return nil, fmt.Errorf("%s: period %s-%s is empty", name, wp.Start, wp.End)
+			}
+			s.days[weekday] = append(s.days[weekday], period{start: start, end: end})
+		}
+
+		day := s.days[weekday]
+		sort.Slice(day, func(i, j int) bool { return day[i].start < day[j].start })
+		for i := 1; i < len(day); i++ {
+			if day[i].start < day[i-1].end {
+				return nil, fmt.Errorf("%s: working periods overlap", name)
+			}
+		}
+		working = working || len(day) > 0
+	}
+	if !working {
+		return nil, fmt.Errorf("workingHours must contain at least one period")
+	}
+
+	for _, h := range c.Holidays {
+		if _, err := time.Parse(holidayLayout, h); err != nil {
+			return nil, fmt.Errorf("invalid holiday %q, expected YYYY-MM-DD", h)
+		}
+		s.holidays[h] = struct{}{}
+	}
+
+	return s, nil
+}
+
+func parseWeekday(name string) (time.Weekday, bool) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(name, d.String()) {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// parseClock разбирает "HH:MM" в минуты от начала дня
+func parseClock(value string) (int, error) {
+	var hours, minutes int
+	if _, err := fmt.Sscanf(value, "%d:%d", &hours, &minutes); err != nil || len(value) != 5 {
+		return 0, fmt.Errorf("expected HH:MM, got %q", value)
+	}
+	if hours < 0 || minutes < 0 || minutes > 59 || hours*60+minutes > 24*60 {
+		return 0, fmt.Errorf("time %q is out of range", value)
+	}
+	return hours*60 + minutes, nil
+}
+
+// calendarLookupChangeID - маркер версии кода, начиная с которой календарь загружается local activity, а не SideEffect
+const calendarLookupChangeID = "calendar-local-activity"
+
+// calendarUnavailableErrorType - тип ошибки local activity, когда календаря нет и повторять загрузку бесполезно
+const calendarUnavailableErrorType = "CalendarUnavailable"
+
+// calendarOptions - опции local activity, загружающей календарь.
+// Повторы не ограничены: временная ошибка БД не должна завершать выполнение.
+var calendarOptions = workflow.LocalActivityOptions{
+	StartToCloseTimeout: 10 * time.Second,
+	RetryPolicy: &temporal.RetryPolicy{
+		InitialInterval:    time.Second,
+		BackoffCoefficient: 2,
+		MaximumInterval:    time.Minute,
+	},
+}
+
+// calendarLookup - результат загрузки календаря, сохраняемый в истории через SideEffect
+type calendarLookup struct {
+	Calendar *Calendar `json:"calendar,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// businessDeadline вычисляет срок, когда пройдет duration рабочего времени календаря calendarName.
+// Календарь загружается local activity, поэтому при replay срок не меняется, даже если календарь изменили.
+func (e *WorkflowEngine) businessDeadline(ctx workflow.Context, calendarName, duration string) (time.Time, error) {
+	d, err := time.ParseDuration(duration)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid business duration %q: %w", duration, err)
+	}
+	if calendarName == "" {
+		return time.Time{}, fmt.Errorf("business duration requires calendar")
+	}
+
+	var calendar Calendar
+	if workflow.GetVersion(ctx, calendarLookupChangeID, workflow.DefaultVersion, 1) == workflow.DefaultVersion {
+		calendar, err = e.sideEffectCalendar(ctx, calendarName)
+	} else {
+		ctx := workflow.WithLocalActivityOptions(ctx, calendarOptions)
+		err = workflow.ExecuteLocalActivity(ctx, e.loadCalendar, calendarName).Get(ctx, &calendar)
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get calendar %s: %w", calendarName, err)
+	}
+
+	return calendar.Deadline(workflow.Now(ctx), d)
+}
+
+// loadCalendar - local activity: отсутствующий календарь не повторяется, остальные ошибки - повторяются
+func (e *WorkflowEngine) loadCalendar(ctx context.Context, name string) (Calendar, error) {
+	if e.calendars == nil {
+		return Calendar{}, temporal.NewNonRetryableApplicationError("calendars are not configured", calendarUnavailableErrorType, nil)
+	}
+	calendar, err := e.calendars.GetCalendar(name)
+	if errors.Is(err, ErrCalendarNotFound) {
+		return Calendar{}, temporal.NewNonRetryableApplicationError(err.Error(), calendarUnavailableErrorType, err)
+	}
+	return calendar, err
+}
+
+// sideEffectCalendar воспроизводит загрузку календаря через SideEffect для выполнений, начатых
+// до перехода на local activity: результат берется из истории
+func (e *WorkflowEngine) sideEffectCalendar(ctx workflow.Context, calendarName string) (Calendar, error) {
+	var lookup calendarLookup
+	err := workflow.SideEffect(ctx, func(ctx workflow.Context) interface{} {
+		if e.calendars == nil {
+			return calendarLookup{Error: "calendars are not configured"}
+		}
+		calendar, err := e.calendars.GetCalendar(calendarName)
+		if err != nil {
+			return calendarLookup{Error: err.Error()}
+		}
+		return calendarLookup{Calendar: &calendar}
+	}).Get(&lookup)
+	if err != nil {
+		return Calendar{}, err
+	}
+	if lookup.Calendar == nil {
+		return Calendar{}, errors.New(lookup.Error)
+	}
+	return *lookup.Calendar, nil
+}
+
+// CalendarNames returns the names of calendars referenced by the states, including nested ones
+func CalendarNames(states []StateDefinition) []string {
+	var names []string
+	for _, state := range states {
+		if state.Calendar != "" {
+			names = append(names, state.Calendar)
+		}
+		for _, event := range state.Events {
+			if event.Calendar != "" {
+				names = append(names, event.Calendar)
+			}
+			names = append(names, CalendarNames(event.Actions)...)
+		}
+		names = append(names, CalendarNames(state.Actions)...)
+		for _, branch := range state.Branches {
+			names = append(names, CalendarNames(branch.States)...)
+		}
+		if state.Iterator != nil {
+			names = append(names, CalendarNames(state.Iterator.States)...)
+		}
+	}
+	return names
+}
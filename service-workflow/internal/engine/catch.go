@@ -0,0 +1,96 @@
+package engine
+
+import (
+	"errors"
+	"strings"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// CatchRule описывает переход в восстановительное состояние при ошибке
+type CatchRule struct {
+	ErrorTypes      []string `json:"errorTypes,omitempty"`      // Тип ApplicationError, "Timeout", "Canceled"; пусто или "*" - любая ошибка
+	MessageContains string   `json:"messageContains,omitempty"` // Подстрока в тексте ошибки
+	Next            string   `json:"next"`
+	Output          string   `json:"output,omitempty"` // Ключ state, куда сохраняется информация об ошибке
+}
+
+// matchCatch возвращает первое подходящее под ошибку правило или nil
+func matchCatch(rules []CatchRule, err error) *CatchRule {
+	errType := errorType(err)
+
+	for i := range rules {
+		rule := &rules[i]
+
+		if len(rule.ErrorTypes) > 0 && !containsErrorType(rule.ErrorTypes, errType) {
+			continue
+		}
+		if rule.MessageContains != "" && !strings.Contains(err.Error(), rule.MessageContains) {
+			continue
+		}
+		return rule
+	}
+
+	return nil
+}
+
+func containsErrorType(types []string, errType string) bool {
+	for _, t := range types {
+		if t == "*" || t == errType {
+			return true
+		}
+	}
+	return false
+}
+
+// errorType возвращает тип ошибки, который можно указать в errorTypes
+func errorType(err error) string {
+	var appErr *temporal.ApplicationError
+	if errors.As(err, &appErr) {
+		return appErr.Type()
+	}
+
+	var timeoutErr *temporal.TimeoutError
+	if errors.As(err, &timeoutErr) {
+		return "Timeout"
+	}
+
+	var canceledErr *temporal.CanceledError
+	if errors.As(err, &canceledErr) {
+		return "Canceled"
+	}
+
+	return ""
+}
+
+// errorInfo формирует описание ошибки для сохранения в state
+func errorInfo(stateName string, err error) map[string]interface{} {
+	return map[string]interface{}{
+		"state":   stateName,
+		"type":    errorType(err),
+		"message": err.Error(),
+	}
+}
+
+// compensate выполняет компенсирующие activity в обратном порядке (saga).
+// Ошибки компенсаций логируются и не прерывают откат остальных шагов.
+func (e *WorkflowEngine) compensate(ctx workflow.Context, compensations []StateDefinition, state map[string]interface{}) {
+	if len(compensations) == 0 {
+		return
+	}
+
+	logger := workflow.GetLogger(ctx)
+
+	// Компенсации должны выполниться, даже если workflow был отменен
+	ctx, _ = workflow.NewDisconnectedContext(ctx)
+
+	for i := len(compensations) - 1; i >= 0; i-- {
+		compensation := compensations[i]
+		logger.Info("Running compensation", "name", compensation.Name, "activity", compensation.ActivityName)
+
+		if err := e.executeActivity(ctx, compensation, state); err != nil {
+			logger.Error("Compensation failed", "name", compensation.Name, "error", err)
+		}
+	}
+}
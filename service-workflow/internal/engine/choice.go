@@ -0,0 +1,212 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// ChoiceRule описывает ветку choice-состояния: если условие истинно, переходим в Next
+type ChoiceRule struct {
+	Condition Condition `json:"condition"`
+	Next      string    `json:"next"`
+}
+
+// Condition описывает условие над state map.
+// Либо задается сравнение (Variable + Operator + Value), либо комбинация And/Or/Not.
+// Отсутствующая переменная не равна никакому значению: ne для нее истинно, остальные сравнения ложны.
+type Condition struct {
+	Variable string          `json:"variable,omitempty"` // Например: "$.input.Message.Channel"
+	Operator string          `json:"operator,omitempty"` // eq, ne, gt, gte, lt, lte, exists, in
+	Value    json.RawMessage `json:"value,omitempty"`    // Литерал или ссылка "$.a.b"
+	And      []Condition     `json:"and,omitempty"`
+	Or       []Condition     `json:"or,omitempty"`
+	Not      *Condition      `json:"not,omitempty"`
+}
+
+// missingNotEqualChangeID - маркер версии кода, начиная с которой ne истинно для отсутствующей переменной
+const missingNotEqualChangeID = "missing-not-equal"
+
+// executeChoice вычисляет условия по порядку и возвращает имя следующего состояния
+func (e *WorkflowEngine) executeChoice(ctx workflow.Context, def StateDefinition, state map[string]interface{}) (string, error) {
+	logger := workflow.GetLogger(ctx)
+
+	for i, rule := range def.Choices {
+		matched, err := evaluateCondition(ctx, rule.Condition, state)
+		if err != nil {
+			return "", fmt.Errorf("choice rule %d: %w", i, err)
+		}
+		if matched {
+			logger.Info("Choice rule matched", "name", def.Name, "rule", i, "next", rule.Next)
+			return rule.Next, nil
+		}
+	}
+
+	if def.Default == "" {
+		return "", fmt.Errorf("no choice rule matched and no default for state %s", def.Name)
+	}
+
+	logger.Info("Choice default taken", "name", def.Name, "next", def.Default)
+	return def.Default, nil
+}
+
+// evaluateCondition рекурсивно вычисляет условие над state map
+func evaluateCondition(ctx workflow.Context, cond Condition, state map[string]interface{}) (bool, error) {
+	switch {
+	case len(cond.And) > 0:
+		for _, c := range cond.And {
+			ok, err := evaluateCondition(ctx, c, state)
+			if err != nil || !ok {
+				return false, err
+			}
+		}
+		return true, nil
+
+	case len(cond.Or) > 0:
+		for _, c := range cond.Or {
+			ok, err := evaluateCondition(ctx, c, state)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				return true, nil
+			}
+		}
+		return false, nil
+
+	case cond.Not != nil:
+		ok, err := evaluateCondition(ctx, *cond.Not, state)
+		if err != nil {
+			return false, err
+		}
+		return !ok, nil
+	}
+
+	if cond.Variable == "" {
+		return false, fmt.Errorf("condition variable is required")
+	}
+
+	actual, found := getNestedValue(state, strings.TrimPrefix(cond.Variable, "$."))
+
+	if cond.Operator == "exists" {
+		// По умолчанию проверяем наличие, "value": false проверяет отсутствие
+		want := true
+		if len(cond.Value) > 0 {
+			if err := json.Unmarshal(cond.Value, &want); err != nil {
+				return false, fmt.Errorf("exists expects boolean value: %w", err)
+			}
+		}
+		return (found && !isNil(actual)) == want, nil
+	}
+
+	if !found {
+		// Выполнения, начатые раньше, считают ne ложным и для отсутствующей переменной, как при записи истории
+		if cond.Operator == "ne" &&
+			workflow.GetVersion(ctx, missingNotEqualChangeID, workflow.DefaultVersion, 1) != workflow.DefaultVersion {
+			return true, nil
+		}
+		return false, nil
+	}
+
+	expected, err := parseValue(ctx, cond.Value, state)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse condition value: %w", err)
+	}
+
+	switch cond.Operator {
+	case "eq", "":
+		return valuesEqual(actual, expected), nil
+	case "ne":
+		return !valuesEqual(actual, expected), nil
+	case "gt", "gte", "lt", "lte":
+		cmp, err := compareValues(actual, expected)
+		if err != nil {
+			return false, err
+		}
+		switch cond.Operator {
+		case "gt":
+			return cmp > 0, nil
+		case "gte":
+			return cmp >= 0, nil
+		case "lt":
+			return cmp < 0, nil
+		default:
+			return cmp <= 0, nil
+		}
+	case "in":
+		list := reflect.ValueOf(expected)
+		if list.Kind() != reflect.Slice && list.Kind() != reflect.Array {
+			return false, fmt.Errorf("operator in expects a list value")
+		}
+		for i := 0; i < list.Len(); i++ {
+			if valuesEqual(actual, list.Index(i).Interface()) {
+				return true, nil
+			}
+		}
+		return false, nil
+	default:
+		return false, fmt.Errorf("unknown condition operator: %s", cond.Operator)
+	}
+}
+
+// valuesEqual сравнивает значения с учетом того, что числа из JSON приходят как float64,
+// а поля protobuf-структур - как int64/int32 и т.п.
+func valuesEqual(a, b interface{}) bool {
+	if af, ok := toFloat(a); ok {
+		if bf, ok := toFloat(b); ok {
+			return af == bf
+		}
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// compareValues возвращает -1, 0 или 1 для чисел и строк
+func compareValues(a, b interface{}) (int, error) {
+	if af, ok := toFloat(a); ok {
+		if bf, ok := toFloat(b); ok {
+			switch {
+			case af < bf:
+				return -1, nil
+			case af > bf:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	}
+
+	as, aok := a.(string)
+	bs, bok := b.(string)
+	if aok && bok {
+		return strings.Compare(as, bs), nil
+	}
+
+	return 0, fmt.Errorf("cannot compare %T with %T", a, b)
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	val := reflect.ValueOf(v)
+	switch val.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(val.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(val.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return val.Float(), true
+	}
+	return 0, false
+}
+
+func isNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	val := reflect.ValueOf(v)
+	switch val.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return val.IsNil()
+	}
+	return false
+}
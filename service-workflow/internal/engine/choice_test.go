@@ -0,0 +1,60 @@
+package engine
+
+import (
+	"encoding/json"
+	"testing"
+
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+)
+
+// evalCondition вычисляет условие внутри тестового workflow: условиям нужен его контекст
+func evalCondition(t *testing.T, condition string, state map[string]interface{}) bool {
+	t.Helper()
+
+	var cond Condition
+	if err := json.Unmarshal([]byte(condition), &cond); err != nil {
+		t.Fatalf("invalid condition: %v", err)
+	}
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.ExecuteWorkflow(func(ctx workflow.Context) (bool, error) {
+		return evaluateCondition(ctx, cond, state)
+	})
+
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("%s: %v", condition, err)
+	}
+	var result bool
+	if err := env.GetWorkflowResult(&result); err != nil {
+		t.Fatalf("failed to decode result: %v", err)
+	}
+	return result
+}
+
+func TestConditionMissingVariable(t *testing.T) {
+	state := map[string]interface{}{
+		"input": map[string]interface{}{"Channel": "email", "count": float64(3)},
+	}
+
+	tests := []struct {
+		condition string
+		want      bool
+	}{
+		{`{"variable": "$.input.Channel", "operator": "ne", "value": "chat"}`, true},
+		{`{"variable": "$.input.Channel", "operator": "ne", "value": "email"}`, false},
+		{`{"variable": "$.input.Priority", "operator": "ne", "value": "high"}`, true},
+		{`{"variable": "$.input.Priority", "operator": "eq", "value": "high"}`, false},
+		{`{"variable": "$.input.Priority", "operator": "gt", "value": 1}`, false},
+		{`{"variable": "$.input.Priority", "operator": "in", "value": ["high"]}`, false},
+		{`{"variable": "$.input.Priority", "operator": "exists", "value": false}`, true},
+		{`{"not": {"variable": "$.input.Priority", "operator": "ne", "value": "high"}}`, false},
+	}
+
+	for _, tt := range tests {
+		if got := evalCondition(t, tt.condition, state); got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.condition, got, tt.want)
+		}
+	}
+}
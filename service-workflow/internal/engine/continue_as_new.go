@@ -0,0 +1,282 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+	"google.golang.org/protobuf/encoding/protojson"
+	protov2 "google.golang.org/protobuf/proto"
+)
+
+// ContinueAsNewPolicy задает пороги истории, после которых workflow продолжается новым запуском.
+// Проверка выполняется на переходах между состояниями верхнего уровня и при ожидании waitForSignal и select.
+type ContinueAsNewPolicy struct {
+	MaxHistoryEvents int  `json:"maxHistoryEvents,omitempty"` // Число событий в истории
+	MaxHistoryBytes  int  `json:"maxHistoryBytes,omitempty"`  // Размер истории в байтах
+	WhenSuggested    bool `json:"whenSuggested,omitempty"`    // Также когда сервер Temporal предлагает continue-as-new
+}
+
+// Continuation - данные, которые переносятся в новый запуск при continue-as-new
+type Continuation struct {
+	State         map[string]CarriedValue `json:"state"`
+	Position      string                  `json:"position"`                // Состояние верхнего уровня, с которого продолжить
+	Deadline      *time.Time              `json:"deadline,omitempty"`      // Оставшийся таймаут прерванного waitForSignal
+	Deadlines     map[string]time.Time    `json:"deadlines,omitempty"`     // Сроки таймеров прерванного select по именам событий
+	Signals       []BufferedSignal        `json:"signals,omitempty"`       // Полученные, но не обработанные сигналы
+	Handlers      []StateDefinition       `json:"handlers,omitempty"`      // Активные фоновые обработчики signal-состояний
+	Compensations []StateDefinition       `json:"compensations,omitempty"` // Накопленные компенсации
+}
+
+// CarriedValue - значение state с именем Go-типа, чтобы после переноса восстановить тот же тип
+type CarriedValue struct {
+	Type  string          `json:"type,omitempty"`
+	Value json.RawMessage `json:"value"`
+}
+
+// BufferedSignal - сигнал, полученный до continue-as-new и не обработанный
+type BufferedSignal struct {
+	Name    string          `json:"name"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// ContinueAsNewError is returned by the engine when the workflow has to continue as new.
+// The caller owns the workflow arguments and turns it into workflow.NewContinueAsNewError.
+type ContinueAsNewError struct {
+	Continuation Continuation
+}
+
+func (e *ContinueAsNewError) Error() string {
+	return "continue as new from state " + e.Continuation.Position
+}
+
+// errCheckpoint прерывает ожидание waitForSignal и select, когда история выросла из-за фоновых обработчиков
+var errCheckpoint = errors.New("checkpoint requested")
+
+// historyExceeded проверяет пороги политики; значения истории детерминированы при replay
+func historyExceeded(ctx workflow.Context, policy *ContinueAsNewPolicy) bool {
+	if policy == nil {
+		return false
+	}
+
+	info := workflow.GetInfo(ctx)
+	switch {
+	case policy.MaxHistoryEvents > 0 && info.GetCurrentHistoryLength() >= policy.MaxHistoryEvents:
+		return true
+	case policy.MaxHistoryBytes > 0 && info.GetCurrentHistorySize() >= policy.MaxHistoryBytes:
+		return true
+	case policy.WhenSuggested && info.GetContinueAsNewSuggested():
+		return true
+	}
+	return false
+}
+
+// continueAsNew собирает продолжение: дожидается фоновых обработчиков сигналов,
+// вычитывает необработанные сигналы и сериализует state
+func (e *WorkflowEngine) continueAsNew(ctx workflow.Context, r *run, position string, state map[string]interface{}) error {
+	// Сигнал, который обработчик уже получил, должен быть обработан до переноса
+	if err := workflow.Await(ctx, func() bool { return r.busyHandlers == 0 }); err != nil {
+		return err
+	}
+
+	carried, err := carryState(state)
+	if err != nil {
+		return fmt.Errorf("failed to carry state: %w", err)
+	}
+
+	cont := Continuation{
+		State:         carried,
+		Position:      position,
+		Deadline:      r.waitDeadline,
+		Deadlines:     r.eventDeadlines,
+		Handlers:      r.handlers,
+		Compensations: r.compensations,
+	}
+
+	for _, name := range signalNames(r.def.States) {
+		for _, payload := range r.inbox[name] {
+			cont.Signals = append(cont.Signals, BufferedSignal{Name: name, Payload: payload})
+		}
+
+		ch := workflow.GetSignalChannel(ctx, name)
+		for {
+			var payload interface{}
+			if !ch.ReceiveAsync(&payload) {
+				break
+			}
+			data, err := json.Marshal(payload)
+			if err != nil {
+				return fmt.Errorf("failed to buffer signal %s: %w", name, err)
+			}
+			cont.Signals = append(cont.Signals, BufferedSignal{Name: name, Payload: data})
+		}
+	}
+
+	workflow.GetLogger(ctx).Info("Continuing as new",
+		"position", position,
+		"historyLength", workflow.GetInfo(ctx).GetCurrentHistoryLength(),
+		"signals", len(cont.Signals))
+	return &ContinueAsNewError{Continuation: cont}
+}
+
+// resume восстанавливает данные продолжения в state и run
+func (e *WorkflowEngine) resume(ctx workflow.Context, r *run, cont *Continuation, state map[string]interface{}) error {
+	restored, err := e.restoreState(cont.State)
+	if err != nil {
+		return fmt.Errorf("failed to restore state: %w", err)
+	}
+	for k, v := range restored {
+		state[k] = v
+	}
+
+	r.compensations = cont.Compensations
+	r.resumeDeadline = cont.Deadline
+	r.resumeEventDeadlines = cont.Deadlines
+	r.inbox = make(map[string][]json.RawMessage)
+	for _, signal := range cont.Signals {
+		r.inbox[signal.Name] = append(r.inbox[signal.Name], signal.Payload)
+	}
+
+	// Фоновые обработчики запускаются заново, перенесенные сигналы они получат первыми
+	for _, handler := range cont.Handlers {
+		e.executeSignalHandler(ctx, r, handler, state)
+	}
+	return nil
+}
+
+// takeBuffered возвращает сигнал, перенесенный из предыдущего запуска
+func (r *run) takeBuffered(name string) (json.RawMessage, bool) {
+	pending := r.inbox[name]
+	if len(pending) == 0 {
+		return nil, false
+	}
+	r.inbox[name] = pending[1:]
+	return pending[0], true
+}
+
+// signalNames возвращает имена всех сигналов, которые может получать определение
+func signalNames(states []StateDefinition) []string {
+	var names []string
+	for _, state := range states {
+		switch state.Type {
+		case "signal":
+			names = append(names, state.SignalName)
+		case "timer":
+			if state.SignalName != "" {
+				names = append(names, state.SignalName+"_cancel")
+			}
+		}
+		for _, signal := range state.Signals {
+			names = append(names, signal.Name)
+		}
+		for _, event := range state.Events {
+			if event.Signal != "" {
+				names = append(names, event.Signal)
+			}
+			names = append(names, signalNames(event.Actions)...)
+		}
+		names = append(names, signalNames(state.Actions)...)
+		for _, branch := range state.Branches {
+			names = append(names, signalNames(branch.States)...)
+		}
+		if state.Iterator != nil {
+			names = append(names, signalNames(state.Iterator.States)...)
+		}
+	}
+
+	// Убираем повторы, сохраняя порядок
+	seen := make(map[string]struct{}, len(names))
+	result := names[:0]
+	for _, name := range names {
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		result = append(result, name)
+	}
+	return result
+}
+
+// carryState сериализует state; protobuf-сообщения - через protojson
+func carryState(state map[string]interface{}) (map[string]CarriedValue, error) {
+	result := make(map[string]CarriedValue, len(state))
+	for key, value := range state {
+		var (
+			data []byte
+			err  error
+		)
+		if msg, ok := value.(protov2.Message); ok && !isNil(value) {
+			data, err = protojson.Marshal(msg)
+		} else {
+			data, err = json.Marshal(value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+
+		carried := CarriedValue{Value: data}
+		if value != nil {
+			carried.Type = reflect.TypeOf(value).String()
+		}
+		result[key] = carried
+	}
+	return result, nil
+}
+
+// restoreState декодирует перенесенный state. Значения известных типов (результаты activity
+// и payload сигналов) восстанавливаются в исходный тип, остальные - как JSON.
+func (e *WorkflowEngine) restoreState(carried map[string]CarriedValue) (map[string]interface{}, error) {
+	types := e.knownTypes()
+
+	result := make(map[string]interface{}, len(carried))
+	for key, value := range carried {
+		t, ok := types[value.Type]
+		if !ok {
+			var v interface{}
+			if err := json.Unmarshal(value.Value, &v); err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			result[key] = v
+			continue
+		}
+
+		if t.Kind() == reflect.Ptr && t.Implements(protoMessageType) {
+			ptr := reflect.New(t.Elem())
+			if err := protojson.Unmarshal(value.Value, ptr.Interface().(protov2.Message)); err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			result[key] = ptr.Interface()
+			continue
+		}
+
+		ptr := reflect.New(t)
+		if err := json.Unmarshal(value.Value, ptr.Interface()); err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		result[key] = ptr.Elem().Interface()
+	}
+	return result, nil
+}
+
+var protoMessageType = reflect.TypeOf((*protov2.Message)(nil)).Elem()
+
+// knownTypes возвращает типы, которые движок сам кладет в state
+func (e *WorkflowEngine) knownTypes() map[string]reflect.Type {
+	types := make(map[string]reflect.Type)
+	for _, def := range e.activities.List() {
+		if t := def.OutputType(); t != nil {
+			types[t.String()] = t
+		}
+	}
+
+	payloadTypesMutex.RLock()
+	for _, t := range payloadTypes {
+		types[t.String()] = t
+	}
+	payloadTypesMutex.RUnlock()
+
+	return types
+}
@@ -2,50 +2,87 @@ package engine
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
-	"strings"
 	"time"
 
 	"go.temporal.io/sdk/client"
 	"go.temporal.io/sdk/workflow"
 
-	"github.com/aimustaev/service-workflow/internal/generated/proto"
+	act "github.com/aimustaev/service-workflow/internal/activity"
+	"github.com/aimustaev/service-workflow/internal/valuepath"
 )
 
 type WorkflowDefinition struct {
-	Name        string            `json:"name"`
-	Version     string            `json:"version"`
-	States      []StateDefinition `json:"states"`
-	Timeouts    Timeouts          `json:"timeouts"`
-	InputSchema json.RawMessage   `json:"inputSchema"`
+	Name          string               `json:"name"`
+	Version       string               `json:"version"`
+	States        []StateDefinition    `json:"states"`
+	Timeouts      Timeouts             `json:"timeouts"`
+	Retry         *RetryPolicy         `json:"retry,omitempty"` // Политика повторов по умолчанию для всех activity
+	InputSchema   json.RawMessage      `json:"inputSchema"`
+	StartAt       string               `json:"startAt,omitempty"`       // Начальное состояние, по умолчанию первое в списке
+	ContinueAsNew *ContinueAsNewPolicy `json:"continueAsNew,omitempty"` // Пороги истории для continue-as-new
+	Schedule      *Schedule            `json:"schedule,omitempty"`      // Запуск по расписанию, без входящего сообщения
 }
 
 type StateDefinition struct {
-	Name          string            `json:"name"`
-	Type          string            `json:"type"` // activity, signal, timer, etc.
-	ActivityName  string            `json:"activityName,omitempty"`
-	Input         json.RawMessage   `json:"input,omitempty"`
-	Output        string            `json:"output,omitempty"`
-	OutputSchema  json.RawMessage   `json:"outputSchema,omitempty"`
-	SignalName    string            `json:"signalName,omitempty"`
-	Actions       []StateDefinition `json:"actions,omitempty"`
-	Concurrent    bool              `json:"concurrent,omitempty"`
-	Timeouts      Timeouts          `json:"timeouts,omitempty"`
-	TimerDuration string            `json:"timerDuration,omitempty"` // Например: "10s", "1m30s"
+	Name              string                     `json:"name"`
+	Type              string                     `json:"type"` // activity, signal, timer, etc.
+	ActivityName      string                     `json:"activityName,omitempty"`
+	Input             json.RawMessage            `json:"input,omitempty"`
+	Output            string                     `json:"output,omitempty"`
+	OutputSchema      json.RawMessage            `json:"outputSchema,omitempty"`
+	SignalName        string                     `json:"signalName,omitempty"`
+	Actions           []StateDefinition          `json:"actions,omitempty"`
+	Concurrent        bool                       `json:"concurrent,omitempty"`
+	Timeouts          Timeouts                   `json:"timeouts,omitempty"`
+	Retry             *RetryPolicy               `json:"retry,omitempty"`
+	TimerDuration     string                     `json:"timerDuration,omitempty"`    // Например: "10s", "1m30s"
+	BusinessDuration  string                     `json:"businessDuration,omitempty"` // Длительность таймера в рабочем времени календаря, например "4h"
+	Calendar          string                     `json:"calendar,omitempty"`         // Календарь для businessDuration и businessTimeout
+	Choices           []ChoiceRule               `json:"choices,omitempty"`
+	Default           string                     `json:"default,omitempty"` // Состояние, если ни одно условие choice не сработало
+	Next              string                     `json:"next,omitempty"`    // Следующее состояние, по умолчанию следующее в списке
+	End               bool                       `json:"end,omitempty"`     // Завершить workflow после этого состояния
+	Catch             []CatchRule                `json:"catch,omitempty"`
+	Compensate        *StateDefinition           `json:"compensate,omitempty"`        // Activity, откатывающая шаг при падении workflow
+	Signals           []SignalWait               `json:"signals,omitempty"`           // Сигналы, которых ждет waitForSignal
+	Events            []SelectEvent              `json:"events,omitempty"`            // Сигналы и таймеры select-состояния, срабатывает первое
+	Timeout           string                     `json:"timeout,omitempty"`           // Таймаут ожидания waitForSignal или срок userTask, например "24h"
+	BusinessTimeout   string                     `json:"businessTimeout,omitempty"`   // Таймаут waitForSignal или срок userTask в рабочем времени календаря
+	TimeoutNext       string                     `json:"timeoutNext,omitempty"`       // Переход по таймауту
+	FormSchema        json.RawMessage            `json:"formSchema,omitempty"`        // JSON Schema результата userTask, проверяется API при выполнении задачи
+	PayloadType       string                     `json:"payloadType,omitempty"`       // Тип payload сигнала для signal-состояния
+	Branches          []Branch                   `json:"branches,omitempty"`          // Ветки parallel-состояния
+	Join              string                     `json:"join,omitempty"`              // Условие завершения parallel: all, any, first
+	JoinCount         int                        `json:"joinCount,omitempty"`         // Сколько веток ждать при join = first
+	ItemsPath         string                     `json:"itemsPath,omitempty"`         // Ссылка "$.key" на список для foreach
+	Iterator          *Iterator                  `json:"iterator,omitempty"`          // Тело цикла foreach/while
+	MaxConcurrency    int                        `json:"maxConcurrency,omitempty"`    // Сколько итераций foreach выполнять одновременно, по умолчанию 1
+	Condition         *Condition                 `json:"condition,omitempty"`         // Условие продолжения while
+	MaxIterations     *int                       `json:"maxIterations,omitempty"`     // Ограничение числа итераций while, по умолчанию 100
+	Workflow          string                     `json:"workflow,omitempty"`          // Имя конфигурации для subworkflow
+	Mode              string                     `json:"mode,omitempty"`              // Режим subworkflow: wait (по умолчанию) или async
+	ParentClosePolicy string                     `json:"parentClosePolicy,omitempty"` // terminate, abandon, requestCancel
+	OutputMapping     map[string]string          `json:"outputMapping,omitempty"`     // Ключ state -> ссылка "$.a.b" на результат subworkflow
+	Values            map[string]json.RawMessage `json:"values,omitempty"`            // Ключ state -> значение или выражение для set
 }
 
 type Timeouts struct {
 	StartToClose    string `json:"startToClose"`
 	ScheduleToClose string `json:"scheduleToClose"`
+	Heartbeat       string `json:"heartbeat,omitempty"`
 }
 
 type WorkflowEngine struct {
 	temporalClient client.Client
-	activities     map[string]interface{}
+	activities     *act.Registry
+	tracer         Tracer         // Получает шаги выполнения при симуляции, обычно nil
+	calendars      CalendarSource // Календари для таймеров в рабочем времени
 }
 
-func NewEngine(temporalClient client.Client, activities map[string]interface{}) *WorkflowEngine {
+func NewEngine(temporalClient client.Client, activities *act.Registry) *WorkflowEngine {
 	return &WorkflowEngine{
 		temporalClient: temporalClient,
 		activities:     activities,
@@ -53,85 +90,231 @@ func NewEngine(temporalClient client.Client, activities map[string]interface{})
 }
 
 func (e *WorkflowEngine) ExecuteWorkflow(ctx workflow.Context, def WorkflowDefinition, input interface{}) (interface{}, error) {
+	return e.execute(ctx, def, input, nil)
+}
+
+// ContinueWorkflow продолжает выполнение определения после continue-as-new
+func (e *WorkflowEngine) ContinueWorkflow(ctx workflow.Context, def WorkflowDefinition, input interface{}, cont Continuation) (interface{}, error) {
+	return e.execute(ctx, def, input, &cont)
+}
+
+func (e *WorkflowEngine) execute(ctx workflow.Context, def WorkflowDefinition, input interface{}, cont *Continuation) (interface{}, error) {
 	state := make(map[string]interface{})
 	state["input"] = input
 
-	// Устанавливаем таймауты по умолчанию
-	activityOptions := workflow.ActivityOptions{
-		StartToCloseTimeout:    time.Minute * 5,  // 5 минут на выполнение активности
-		ScheduleToCloseTimeout: time.Minute * 10, // 10 минут от планирования до завершения
+	// Регистрируем query handlers, чтобы было видно, где находится workflow
+	tracker := &executionTracker{}
+	if err := registerQueries(ctx, tracker, state); err != nil {
+		return nil, fmt.Errorf("failed to register query handlers: %w", err)
 	}
-	ctx = workflow.WithActivityOptions(ctx, activityOptions)
 
-	for _, stateDef := range def.States {
-		switch stateDef.Type {
-		case "activity":
-			err := e.executeActivity(ctx, stateDef, state)
-			if err != nil {
-				return nil, fmt.Errorf("state %s failed: %w", stateDef.Name, err)
-			}
+	// Проверяем входные данные по inputSchema до выполнения первого состояния
+	if err := validateSchema(def.InputSchema, input, "workflow input"); err != nil {
+		return nil, err
+	}
 
-		case "signal":
-			e.executeSignalHandler(ctx, stateDef, state)
+	// Устанавливаем таймауты и retry уровня workflow, состояния могут их переопределить
+	ctx = workflow.WithActivityOptions(ctx, defaultActivityOptions(def))
 
-		case "timer":
-			err := e.executeTimer(ctx, stateDef, state)
-			if err != nil {
-				return nil, fmt.Errorf("timer %s failed: %w", stateDef.Name, err)
-			}
+	r := &run{
+		def:        def,
+		tracker:    tracker,
+		checkpoint: workflow.NewBufferedChannel(ctx, 1),
+	}
+
+	startAt := def.StartAt
+	if cont != nil {
+		if err := e.resume(ctx, r, cont, state); err != nil {
+			return nil, err
+		}
+		startAt = cont.Position
+	}
 
-		default:
-			return nil, fmt.Errorf("unknown state type: %s", stateDef.Type)
+	if err := e.runStates(ctx, r, def.States, startAt, state); err != nil {
+		var continueErr *ContinueAsNewError
+		if !errors.As(err, &continueErr) {
+			e.compensate(ctx, r.compensations, state)
 		}
+		return nil, err
 	}
 
 	return state["output"], nil
 }
 
+// run хранит данные одного выполнения workflow, общие для вложенных списков состояний
+type run struct {
+	def     WorkflowDefinition
+	tracker *executionTracker
+	// Выполненные шаги с компенсацией, откатываются в обратном порядке при падении workflow
+	compensations []StateDefinition
+
+	// Данные для continue-as-new
+	handlers             []StateDefinition            // Запущенные фоновые обработчики сигналов, по одному на сигнал
+	busyHandlers         int                          // Обработчики, которые сейчас обрабатывают сигнал
+	checkpoint           workflow.Channel             // Обработчики просят прервать ожидание, если история выросла
+	inbox                map[string][]json.RawMessage // Сигналы, перенесенные из предыдущего запуска
+	waitDeadline         *time.Time                   // Срок прерванного waitForSignal
+	resumeDeadline       *time.Time                   // Срок waitForSignal, с которого продолжается запуск
+	eventDeadlines       map[string]time.Time         // Сроки таймеров прерванного select
+	resumeEventDeadlines map[string]time.Time         // Сроки таймеров select, с которого продолжается запуск
+}
+
+// hasHandler сообщает, что обработчик сигнала signalName уже запущен
+func (r *run) hasHandler(signalName string) bool {
+	for _, handler := range r.handlers {
+		if handler.SignalName == signalName {
+			return true
+		}
+	}
+	return false
+}
+
+// topLevel сообщает, что states - список состояний верхнего уровня определения
+func (r *run) topLevel(states []StateDefinition) bool {
+	return len(states) > 0 && len(r.def.States) > 0 && &states[0] == &r.def.States[0]
+}
+
+// runStates выполняет список состояний как конечный автомат.
+// Используется и для определения целиком, и для вложенных списков (ветки parallel, тело циклов).
+func (e *WorkflowEngine) runStates(ctx workflow.Context, r *run, states []StateDefinition, startAt string, state map[string]interface{}) error {
+	current, err := startState(states, startAt)
+	if err != nil {
+		return err
+	}
+
+	// Продолжить новым запуском можно только с состояния верхнего уровня
+	top := r.topLevel(states)
+
+	// current = -1 означает завершение
+	for current >= 0 {
+		stateDef := states[current]
+
+		visit := r.tracker.enter(ctx, stateDef)
+		trace := e.traceStart(ctx, stateDef, state)
+		target, err := e.executeState(ctx, r, stateDef, state, top)
+		r.tracker.exit(ctx, visit, err)
+		trace.end(ctx, stateDef, state, err)
+
+		if errors.Is(err, errCheckpoint) {
+			return e.continueAsNew(ctx, r, stateDef.Name, state)
+		}
+
+		if err != nil {
+			rule := matchCatch(stateDef.Catch, err)
+			if rule == nil {
+				return fmt.Errorf("state %s failed: %w", stateDef.Name, err)
+			}
+
+			workflow.GetLogger(ctx).Warn("State failed, error caught", "name", stateDef.Name, "next", rule.Next, "error", err)
+			if rule.Output != "" {
+				state[rule.Output] = errorInfo(stateDef.Name, err)
+			}
+			target = rule.Next
+		} else {
+			if stateDef.Compensate != nil {
+				r.addCompensation(ctx, *stateDef.Compensate)
+			}
+			if stateDef.End {
+				break
+			}
+		}
+
+		current, err = nextState(states, current, target)
+		if err != nil {
+			return err
+		}
+
+		if top && current >= 0 && historyExceeded(ctx, r.def.ContinueAsNew) {
+			return e.continueAsNew(ctx, r, states[current].Name, state)
+		}
+	}
+
+	return nil
+}
+
+// executeState выполняет одно состояние и возвращает явный переход ("" - обычный переход)
+// top - состояние верхнего уровня, его ожидание можно прервать для continue-as-new.
+func (e *WorkflowEngine) executeState(ctx workflow.Context, r *run, stateDef StateDefinition, state map[string]interface{}, top bool) (string, error) {
+	switch stateDef.Type {
+	case "activity":
+		return "", e.executeActivity(ctx, stateDef, state)
+
+	case "signal":
+		e.executeSignalHandler(ctx, r, stateDef, state)
+		return "", nil
+
+	case "timer":
+		return "", e.executeTimer(ctx, r, stateDef, state)
+
+	case "choice":
+		return e.executeChoice(ctx, stateDef, state)
+
+	case "waitForSignal":
+		return e.executeWaitForSignal(ctx, r, stateDef, state, top)
+
+	case "select":
+		return e.executeSelect(ctx, r, stateDef, state, top)
+
+	case "userTask":
+		return e.executeUserTask(ctx, stateDef, state)
+
+	case "parallel":
+		return "", e.executeParallel(ctx, r, stateDef, state)
+
+	case "foreach":
+		return "", e.executeForeach(ctx, r, stateDef, state)
+
+	case "while":
+		return "", e.executeWhile(ctx, r, stateDef, state)
+
+	case "subworkflow":
+		return "", e.executeSubworkflow(ctx, stateDef, state)
+
+	case "set":
+		return "", e.executeSet(ctx, stateDef, state)
+
+	default:
+		return "", fmt.Errorf("unknown state type: %s", stateDef.Type)
+	}
+}
+
 func (e *WorkflowEngine) executeActivity(ctx workflow.Context, def StateDefinition, state map[string]interface{}) error {
 	logger := workflow.GetLogger(ctx)
 	// Парсим входные данные
-	input, err := parseInput(def.Input, state)
+	input, err := parseInput(ctx, def.Input, state)
 	if err != nil {
 		return fmt.Errorf("failed to parse input: %w", err)
 	}
 
-	activity := e.activities[def.ActivityName]
+	activity, ok := e.activities.Get(def.ActivityName)
+	if !ok {
+		// Определения проверяются при сохранении, поэтому неизвестное имя значит, что воркер не загрузил
+		// провайдера activity. Падает задача workflow, а не он сам: Temporal повторит ее, пока задачу
+		// не возьмет воркер, у которого провайдер доступен.
+		panic(fmt.Sprintf("unknown activity %s in state %s: activity provider is not loaded by this worker", def.ActivityName, def.Name))
+	}
+	ctx = workflow.WithActivityOptions(ctx, stateActivityOptions(ctx, def))
 
-	// Определяем тип выходных данных на основе имени activity
+	// Декодируем результат в тип, объявленный activity в реестре
 	var output interface{}
-	switch def.ActivityName {
-	case "GetOrCreateTicketActivity",
-		"AddMassageToTicketActivity",
-		"ClassifierAcitivity",
-		"SolveTicketAcitivity",
-		"GetTicketByUserActivity",
-		"CreateTicketActivity":
-		var ticketOutput *proto.TicketResponse
-		err = workflow.ExecuteActivity(ctx, activity, input...).Get(ctx, &ticketOutput)
-		output = ticketOutput
-	case "ProcessMessageActivity",
-		"WaitActivity":
-		var errOutput error
-		err = workflow.ExecuteActivity(ctx, activity, input...).Get(ctx, &errOutput)
-		output = errOutput
-	default:
-		err = workflow.ExecuteActivity(ctx, activity, input...).Get(ctx, &output)
+	future := workflow.ExecuteActivity(ctx, activity.Name, input...)
+	if outputType := activity.OutputType(); outputType != nil {
+		result := reflect.New(outputType)
+		err = future.Get(ctx, result.Interface())
+		output = result.Elem().Interface()
+	} else {
+		err = future.Get(ctx, nil)
 	}
 
 	if err != nil {
 		return err
 	}
 
-	//// Если есть схема выходных данных, валидируем результат
-	//if len(def.OutputSchema) > 0 {
-	//	// TODO: Добавить валидацию по схеме
-	//	// Пока просто логируем, что схема есть
-	//	logger.Info("Activity output schema defined", "schema", string(def.OutputSchema))
-	//}
-
-	// Сохраняем результат если нужно
+	// Сохраняем результат если нужно, предварительно проверив его по outputSchema
 	if def.Output != "" {
+		if err := validateSchema(def.OutputSchema, output, "output of "+def.Name); err != nil {
+			return err
+		}
 		state[def.Output] = output
 	}
 
@@ -139,36 +322,119 @@ func (e *WorkflowEngine) executeActivity(ctx workflow.Context, def StateDefiniti
 	return nil
 }
 
-func (e *WorkflowEngine) executeSignalHandler(ctx workflow.Context, def StateDefinition, state map[string]interface{}) {
+// uniqueSignalHandlersChangeID - маркер версии кода, начиная с которой на один сигнал запускается один обработчик
+const uniqueSignalHandlersChangeID = "unique-signal-handlers"
+
+func (e *WorkflowEngine) executeSignalHandler(ctx workflow.Context, r *run, def StateDefinition, state map[string]interface{}) {
+	// При повторном входе в состояние обработчик уже запущен: второй читал бы тот же канал
+	// и переносился бы при continue-as-new вместе с первым
+	if r.hasHandler(def.SignalName) &&
+		workflow.GetVersion(ctx, uniqueSignalHandlersChangeID, workflow.DefaultVersion, 1) != workflow.DefaultVersion {
+		workflow.GetLogger(ctx).Info("Signal handler already running", "name", def.Name, "signal", def.SignalName)
+		return
+	}
+
+	// Обработчик переносится в новый запуск при continue-as-new
+	r.handlers = append(r.handlers, def)
+
+	handle := func(ctx workflow.Context, signalData interface{}) {
+		state["signalPayload"] = signalData
+
+		for _, action := range def.Actions {
+			if action.Type == "activity" {
+				err := e.executeActivity(ctx, action, state)
+				if err != nil {
+					workflow.GetLogger(ctx).Error("Signal handler activity failed", "error", err)
+				}
+			}
+		}
+
+		// История растет и пока основной поток ждет, просим его прерваться для continue-as-new
+		if historyExceeded(ctx, r.def.ContinueAsNew) {
+			r.checkpoint.SendAsync(true)
+		}
+	}
+
+	// receive декодирует сигнал и обрабатывает его; сигнал, который не удалось декодировать, пропускается,
+	// чтобы обработчик продолжал получать следующие
+	receive := func(ctx workflow.Context, decode func(payload interface{}) error) {
+		signalData, err := signalPayload(def.PayloadType, decode)
+		if err != nil {
+			workflow.GetLogger(ctx).Error("Failed to decode signal, skipping it", "name", def.Name, "signal", def.SignalName, "error", err)
+			return
+		}
+		r.busyHandlers++
+		handle(ctx, signalData)
+		r.busyHandlers--
+	}
+
 	workflow.Go(ctx, func(ctx workflow.Context) {
-		signalChan := workflow.GetSignalChannel(ctx, def.SignalName)
+		// Сначала сигналы, перенесенные из предыдущего запуска
 		for {
-			var signalData interface{}
-			signalChan.Receive(ctx, &signalData)
-			state["signalPayload"] = signalData
-
-			for _, action := range def.Actions {
-				if action.Type == "activity" {
-					err := e.executeActivity(ctx, action, state)
-					if err != nil {
-						workflow.GetLogger(ctx).Error("Signal handler activity failed", "error", err)
-					}
-				}
+			raw, ok := r.takeBuffered(def.SignalName)
+			if !ok {
+				break
 			}
+			receive(ctx, func(payload interface{}) error {
+				if payload == nil {
+					return nil
+				}
+				return json.Unmarshal(raw, payload)
+			})
+		}
+
+		signalChan := workflow.GetSignalChannel(ctx, def.SignalName)
+		for {
+			// Сигнал, который SDK не смог декодировать, Receive пропускает сам
+			receive(ctx, func(payload interface{}) error {
+				signalChan.Receive(ctx, payload)
+				return nil
+			})
 		}
 	})
 }
 
-func (e *WorkflowEngine) executeTimer(ctx workflow.Context, def StateDefinition, state map[string]interface{}) error {
-	logger := workflow.GetLogger(ctx)
-
-	if def.TimerDuration == "" {
-		return fmt.Errorf("timer duration not specified for state %s", def.Name)
+// signalPayload декодирует payload сигнала в объявленный тип, без типа - как есть.
+// decode(nil) только вычитывает сигнал: так неизвестный тип не блокирует следующие сигналы.
+func signalPayload(payloadType string, decode func(payload interface{}) error) (interface{}, error) {
+	if payloadType == "" {
+		var data interface{}
+		err := decode(&data)
+		return data, err
 	}
 
-	duration, err := time.ParseDuration(def.TimerDuration)
+	payload, err := newPayload(payloadType)
 	if err != nil {
-		return fmt.Errorf("invalid timer duration for state %s: %w", def.Name, err)
+		_ = decode(nil)
+		return nil, err
+	}
+	if err := decode(payload.Interface()); err != nil {
+		return nil, err
+	}
+	return payload.Elem().Interface(), nil
+}
+
+func (e *WorkflowEngine) executeTimer(ctx workflow.Context, r *run, def StateDefinition, state map[string]interface{}) error {
+	logger := workflow.GetLogger(ctx)
+
+	var duration time.Duration
+	switch {
+	case def.BusinessDuration != "":
+		// Срок считается по рабочему календарю, таймер ждет до него по обычным часам
+		deadline, err := e.businessDeadline(ctx, def.Calendar, def.BusinessDuration)
+		if err != nil {
+			return fmt.Errorf("state %s: %w", def.Name, err)
+		}
+		duration = deadline.Sub(workflow.Now(ctx))
+
+	case def.TimerDuration != "":
+		var err error
+		if duration, err = time.ParseDuration(def.TimerDuration); err != nil {
+			return fmt.Errorf("invalid timer duration for state %s: %w", def.Name, err)
+		}
+
+	default:
+		return fmt.Errorf("timer duration not specified for state %s", def.Name)
 	}
 
 	logger.Info("Starting timer", "name", def.Name, "duration", duration)
@@ -191,11 +457,20 @@ func (e *WorkflowEngine) executeTimer(ctx workflow.Context, def StateDefinition,
 					logger.Error("Post-timer activity failed", "error", err)
 				}
 			case "signal":
-				e.executeSignalHandler(ctx, action, state)
+				e.executeSignalHandler(ctx, r, action, state)
 			}
 		}
 	})
 
+	// Отмена, полученная до continue-as-new
+	if def.SignalName != "" {
+		if _, ok := r.takeBuffered(def.SignalName + "_cancel"); ok {
+			cancel()
+			logger.Info("Timer cancelled by buffered signal", "name", def.Name)
+			return nil
+		}
+	}
+
 	// Добавляем возможность отмены через сигнал
 	if def.SignalName != "" {
 		cancelChan := workflow.GetSignalChannel(ctx, def.SignalName+"_cancel")
@@ -210,9 +485,62 @@ func (e *WorkflowEngine) executeTimer(ctx workflow.Context, def StateDefinition,
 	return nil
 }
 
-func parseInput(inputJSON json.RawMessage, state map[string]interface{}) ([]interface{}, error) {
+// startState возвращает индекс начального состояния
+func startState(states []StateDefinition, startAt string) (int, error) {
+	if len(states) == 0 {
+		return -1, nil
+	}
+	if startAt == "" {
+		return 0, nil
+	}
+
+	idx := stateIndex(states, startAt)
+	if idx < 0 {
+		return -1, fmt.Errorf("unknown startAt state: %s", startAt)
+	}
+	return idx, nil
+}
+
+// nextState возвращает индекс следующего состояния или -1, если workflow завершен.
+// target - явный переход (например, выбранный choice), иначе используется next,
+// а для определений без next - следующее состояние по порядку.
+func nextState(states []StateDefinition, current int, target string) (int, error) {
+	if target == "" {
+		target = states[current].Next
+	}
+
+	if target != "" {
+		idx := stateIndex(states, target)
+		if idx < 0 {
+			return -1, fmt.Errorf("state %s: unknown transition target %s", states[current].Name, target)
+		}
+		return idx, nil
+	}
+
+	if current+1 >= len(states) {
+		return -1, nil
+	}
+	return current + 1, nil
+}
+
+// stateIndex возвращает индекс состояния по имени или -1
+func stateIndex(states []StateDefinition, name string) int {
+	for i, s := range states {
+		if s.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func parseInput(ctx workflow.Context, inputJSON json.RawMessage, state map[string]interface{}) ([]interface{}, error) {
 	var inputs []interface{}
 
+	// Activity без аргументов
+	if len(inputJSON) == 0 {
+		return inputs, nil
+	}
+
 	// Если input - массив
 	if len(inputJSON) > 0 && inputJSON[0] == '[' {
 		var inputArray []json.RawMessage
@@ -221,7 +549,7 @@ func parseInput(inputJSON json.RawMessage, state map[string]interface{}) ([]inte
 		}
 
 		for _, item := range inputArray {
-			val, err := parseValue(item, state)
+			val, err := parseValue(ctx, item, state)
 			if err != nil {
 				return nil, err
 			}
@@ -229,7 +557,7 @@ func parseInput(inputJSON json.RawMessage, state map[string]interface{}) ([]inte
 		}
 	} else {
 		// Если input - одиночное значение
-		val, err := parseValue(inputJSON, state)
+		val, err := parseValue(ctx, inputJSON, state)
 		if err != nil {
 			return nil, err
 		}
@@ -239,29 +567,16 @@ func parseInput(inputJSON json.RawMessage, state map[string]interface{}) ([]inte
 	return inputs, nil
 }
 
-func parseValue(value json.RawMessage, state map[string]interface{}) (interface{}, error) {
-	// Проверяем, является ли значение ссылкой на state (начинается с "$.")
-	if len(value) > 2 && value[0] == '"' && value[1] == '$' {
-		var ref string
-		if err := json.Unmarshal(value, &ref); err != nil {
-			return nil, err
-		}
-
-		if ref[:2] == "$." {
-			key := ref[2:]
-			if val, ok := getNestedValue(state, key); ok {
-				return val, nil
-			}
-			return nil, fmt.Errorf("state key not found: %s", key)
-		}
-	}
-
-	// Если это не ссылка, разбираем как обычное JSON значение
+// parseValue разбирает JSON значение: строка "$.a.b" целиком - ссылка на state,
+// объекты {"$expr": ...} и {"$template": ...} на любой глубине вычисляются, остальное - литералы
+func parseValue(ctx workflow.Context, value json.RawMessage, state map[string]interface{}) (interface{}, error) {
 	var result interface{}
 	if err := json.Unmarshal(value, &result); err != nil {
 		return nil, err
 	}
-	return result, nil
+
+	env := &exprEnv{state: state, now: workflow.Now(ctx)}
+	return resolveValue(result, env, true)
 }
 
 func parseDuration(durStr string) time.Duration {
@@ -276,39 +591,7 @@ func parseDuration(durStr string) time.Duration {
 }
 
 // getNestedValue позволяет получить значение по ключу с точками (например, "input.Message") из вложенных map[string]interface{}.
+// Поддерживаются индексы списков: "tickets[0].Id", отрицательный индекс считается с конца.
 func getNestedValue(state map[string]interface{}, key string) (interface{}, bool) {
-	keys := strings.Split(key, ".")
-	current := interface{}(state)
-
-	for _, k := range keys {
-		// Пытаемся обработать как map[string]interface{}
-		if m, ok := current.(map[string]interface{}); ok {
-			val, exists := m[k]
-			if !exists {
-				return nil, false
-			}
-			current = val
-			continue
-		}
-
-		// Пытаемся обработать как protobuf-сообщение (через рефлексию)
-		val := reflect.ValueOf(current)
-		if val.Kind() == reflect.Ptr {
-			val = val.Elem() // Разыменовываем указатель (*proto.Ticket → proto.Ticket)
-		}
-
-		if val.Kind() != reflect.Struct {
-			return nil, false // Не мапа и не структура — ошибка
-		}
-
-		// Ищем поле в protobuf-структуре
-		field := val.FieldByName(k)
-		if !field.IsValid() {
-			return nil, false
-		}
-
-		current = field.Interface()
-	}
-
-	return current, true
+	return valuepath.Lookup(state, key)
 }
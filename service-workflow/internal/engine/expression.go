@@ -0,0 +1,891 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/aimustaev/service-workflow/internal/valuepath"
+)
+
+// Выражения используются в input, set-состояниях и везде, где значение разбирается через parseValue:
+//
+//	"$.tickets[0].Id"                                - ссылка на state, только значение целиком; при отсутствии ключа - ошибка
+//	{"$expr": "coalesce($.agent, 'nobody')"}         - выражение: арифметика, сравнения, &&, ||, ??, функции
+//	{"$template": "Тикет {{ $.ticket.Id }} закрыт"}  - шаблон, {{ }} подставляет результат выражения
+//
+// Выражения и шаблоны включаются явно объектом-оберткой и работают на любой глубине значения.
+// Остальные строки, в том числе вложенные "$.", "=..." и "{{", остаются литералами, как и до появления выражений.
+// Выражения вычисляются детерминированно: now() берет время из workflow.Now.
+
+// Ключи объектов-оберток, которыми значение помечается как выражение или шаблон
+const (
+	exprKey     = "$expr"
+	templateKey = "$template"
+)
+
+// exprEnv - данные, доступные при вычислении выражения
+type exprEnv struct {
+	state map[string]interface{}
+	now   time.Time
+}
+
+// expr - узел разобранного выражения
+type expr interface {
+	eval(env *exprEnv) (interface{}, error)
+}
+
+type (
+	literalExpr struct{ value interface{} }
+	rootExpr    struct{} // $ - весь state
+	memberExpr  struct {
+		target expr
+		name   string
+	}
+	indexExpr struct {
+		target expr
+		index  expr
+	}
+	unaryExpr struct {
+		op      string
+		operand expr
+	}
+	binaryExpr struct {
+		op          string
+		left, right expr
+	}
+	callExpr struct {
+		name string
+		args []expr
+	}
+)
+
+// isReference - строка целиком является ссылкой на state: "$.a.b" или "$.list[0]"
+func isReference(s string) bool {
+	return strings.HasPrefix(s, "$.")
+}
+
+// wrappedExpression распознает объект-обертку {"$expr": ...} или {"$template": ...}
+// и возвращает ее ключ и исходный текст. ok = false - объект обычный.
+func wrappedExpression(m map[string]interface{}) (kind, source string, ok bool, err error) {
+	if len(m) != 1 {
+		return "", "", false, nil
+	}
+	for _, key := range []string{exprKey, templateKey} {
+		raw, exists := m[key]
+		if !exists {
+			continue
+		}
+		source, isString := raw.(string)
+		if !isString {
+			return "", "", false, fmt.Errorf("%s must be a string, got %T", key, raw)
+		}
+		return key, source, true, nil
+	}
+	return "", "", false, nil
+}
+
+// resolveValue вычисляет ссылку (только если top - значение целиком) и обертки выражений,
+// в том числе вложенные в объекты и массивы
+func resolveValue(value interface{}, env *exprEnv, top bool) (interface{}, error) {
+	switch val := value.(type) {
+	case string:
+		if !top || !isReference(val) {
+			return val, nil
+		}
+		// Ссылка на отсутствующий ключ - ошибка; внутри выражений отсутствующий ключ дает null
+		resolved, ok := getNestedValue(env.state, val[2:])
+		if !ok {
+			return nil, fmt.Errorf("state key not found: %s", val[2:])
+		}
+		return resolved, nil
+
+	case map[string]interface{}:
+		kind, source, ok, err := wrappedExpression(val)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			if kind == templateKey {
+				return evaluateTemplate(source, env)
+			}
+			node, err := parseExpression(source)
+			if err != nil {
+				return nil, err
+			}
+			return node.eval(env)
+		}
+
+		result := make(map[string]interface{}, len(val))
+		for k, v := range val {
+			resolved, err := resolveValue(v, env, false)
+			if err != nil {
+				return nil, err
+			}
+			result[k] = resolved
+		}
+		return result, nil
+
+	case []interface{}:
+		result := make([]interface{}, len(val))
+		for i, v := range val {
+			resolved, err := resolveValue(v, env, false)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = resolved
+		}
+		return result, nil
+	}
+
+	return value, nil
+}
+
+// evaluateTemplate подставляет в строку результаты выражений {{ }}.
+// Если шаблон состоит из одного выражения, возвращается значение без приведения к строке.
+func evaluateTemplate(s string, env *exprEnv) (interface{}, error) {
+	parts, err := splitTemplate(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(parts) == 1 && parts[0].expr != nil {
+		return parts[0].expr.eval(env)
+	}
+
+	var b strings.Builder
+	for _, part := range parts {
+		if part.expr == nil {
+			b.WriteString(part.text)
+			continue
+		}
+		value, err := part.expr.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		b.WriteString(stringify(value))
+	}
+	return b.String(), nil
+}
+
+type templatePart struct {
+	text string
+	expr expr
+}
+
+func splitTemplate(s string) ([]templatePart, error) {
+	var parts []templatePart
+	for {
+		start := strings.Index(s, "{{")
+		if start < 0 {
+			if s != "" {
+				parts = append(parts, templatePart{text: s})
+			}
+			return parts, nil
+		}
+		end := strings.Index(s[start:], "}}")
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed {{ in template")
+		}
+
+		if start > 0 {
+			parts = append(parts, templatePart{text: s[:start]})
+		}
+		node, err := parseExpression(s[start+2 : start+end])
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, templatePart{expr: node})
+		s = s[start+end+2:]
+	}
+}
+
+// ValueRefs returns the top-level state keys a value references: the value itself when it is a "$." reference,
+// and expressions and templates wrapped in {"$expr": ...} or {"$template": ...} at any depth.
+// Other strings are literals and reference nothing.
+func ValueRefs(value interface{}) ([]string, error) {
+	return valueRefs(value, true, nil)
+}
+
+func valueRefs(value interface{}, top bool, keys []string) ([]string, error) {
+	switch val := value.(type) {
+	case string:
+		if !top || !isReference(val) {
+			return keys, nil
+		}
+		key := strings.FieldsFunc(val[2:], func(r rune) bool { return r == '.' || r == '[' })
+		if len(key) == 0 {
+			return nil, fmt.Errorf("empty reference %q", val)
+		}
+		return append(keys, key[0]), nil
+
+	case map[string]interface{}:
+		kind, source, ok, err := wrappedExpression(val)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			if kind == templateKey {
+				parts, err := splitTemplate(source)
+				if err != nil {
+					return nil, err
+				}
+				for _, part := range parts {
+					if part.expr != nil {
+						keys = collectRefs(part.expr, keys)
+					}
+				}
+				return keys, nil
+			}
+			node, err := parseExpression(source)
+			if err != nil {
+				return nil, err
+			}
+			return collectRefs(node, keys), nil
+		}
+
+		fields := make([]string, 0, len(val))
+		for k := range val {
+			fields = append(fields, k)
+		}
+		sort.Strings(fields)
+		for _, k := range fields {
+			if keys, err = valueRefs(val[k], false, keys); err != nil {
+				return nil, err
+			}
+		}
+		return keys, nil
+
+	case []interface{}:
+		var err error
+		for _, v := range val {
+			if keys, err = valueRefs(v, false, keys); err != nil {
+				return nil, err
+			}
+		}
+		return keys, nil
+	}
+
+	return keys, nil
+}
+
+// collectRefs собирает ключи state первого уровня, к которым обращается выражение
+func collectRefs(node expr, keys []string) []string {
+	switch n := node.(type) {
+	case memberExpr:
+		if _, ok := n.target.(rootExpr); ok {
+			return append(keys, n.name)
+		}
+		return collectRefs(n.target, keys)
+	case indexExpr:
+		return collectRefs(n.index, collectRefs(n.target, keys))
+	case unaryExpr:
+		return collectRefs(n.operand, keys)
+	case binaryExpr:
+		return collectRefs(n.right, collectRefs(n.left, keys))
+	case callExpr:
+		for _, arg := range n.args {
+			keys = collectRefs(arg, keys)
+		}
+	}
+	return keys
+}
+
+// --- Разбор ---
+
+type token struct {
+	kind  string // number, string, ident, op, eof
+	text  string
+	value interface{}
+	pos   int // Смещение в байтах от начала выражения
+}
+
+// position переводит смещение в байтах в номер символа, начиная с 1
+func position(s string, offset int) int {
+	return utf8.RuneCountInString(s[:offset]) + 1
+}
+
+func tokenize(s string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(s); {
+		c := s[i]
+		r, _ := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+
+		case c >= '0' && c <= '9':
+			j := i
+			for j < len(s) && (s[j] >= '0' && s[j] <= '9' || s[j] == '.') {
+				j++
+			}
+			n, err := strconv.ParseFloat(s[i:j], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q at position %d", s[i:j], position(s, i))
+			}
+			tokens = append(tokens, token{kind: "number", text: s[i:j], value: n, pos: i})
+			i = j
+
+		case c == '\'' || c == '"':
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(s) && s[j] != c; j++ {
+				if s[j] == '\\' && j+1 < len(s) {
+					j++
+				}
+				b.WriteByte(s[j])
+			}
+			if j >= len(s) {
+				return nil, fmt.Errorf("unterminated string at position %d", position(s, i))
+			}
+			tokens = append(tokens, token{kind: "string", text: s[i : j+1], value: b.String(), pos: i})
+			i = j + 1
+
+		case r == '_' || unicode.IsLetter(r):
+			// Имена ключей бывают не латинскими, поэтому читаем по рунам, а не по байтам
+			j := i
+			for j < len(s) {
+				next, size := utf8.DecodeRuneInString(s[j:])
+				if next != '_' && !unicode.IsLetter(next) && !unicode.IsDigit(next) {
+					break
+				}
+				j += size
+			}
+			tokens = append(tokens, token{kind: "ident", text: s[i:j], pos: i})
+			i = j
+
+		default:
+			op := string(c)
+			if i+1 < len(s) {
+				switch two := s[i : i+2]; two {
+				case "==", "!=", "<=", ">=", "&&", "||", "??":
+					op = two
+				}
+			}
+			if !strings.Contains("$.[](),+-*/%<>!=&|?", op[:1]) {
+				return nil, fmt.Errorf("unexpected character %q at position %d", r, position(s, i))
+			}
+			tokens = append(tokens, token{kind: "op", text: op, pos: i})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{kind: "eof", pos: len(s)}), nil
+}
+
+type parser struct {
+	source string
+	tokens []token
+	pos    int
+}
+
+// errorf формирует ошибку разбора с позицией токена
+func (p *parser) errorf(t token, format string, args ...interface{}) error {
+	return fmt.Errorf(format+" at position %d", append(args, position(p.source, t.pos))...)
+}
+
+// parseExpression разбирает выражение целиком
+func parseExpression(s string) (expr, error) {
+	tokens, err := tokenize(s)
+	if err != nil {
+		return nil, fmt.Errorf("expression %q: %w", s, err)
+	}
+
+	p := &parser{source: s, tokens: tokens}
+	node, err := p.parseBinary(0)
+	if err == nil && p.peek().kind != "eof" {
+		err = p.errorf(p.peek(), "unexpected %q", p.peek().text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("expression %q: %w", s, err)
+	}
+	return node, nil
+}
+
+// binaryPrecedence - приоритеты бинарных операторов, от меньшего к большему
+var binaryPrecedence = [][]string{
+	{"??"},
+	{"||"},
+	{"&&"},
+	{"==", "!="},
+	{"<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != "eof" {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) accept(op string) bool {
+	if t := p.peek(); t.kind == "op" && t.text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(op string) error {
+	if !p.accept(op) {
+		if p.peek().kind == "eof" {
+			return p.errorf(p.peek(), "expected %q, got end of expression", op)
+		}
+		return p.errorf(p.peek(), "expected %q, got %q", op, p.peek().text)
+	}
+	return nil
+}
+
+func (p *parser) parseBinary(level int) (expr, error) {
+	if level == len(binaryPrecedence) {
+		return p.parseUnary()
+	}
+
+	left, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		t := p.peek()
+		if t.kind != "op" || !containsString(binaryPrecedence[level], t.text) {
+			return left, nil
+		}
+		p.next()
+
+		right, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = binaryExpr{op: t.text, left: left, right: right}
+	}
+}
+
+func (p *parser) parseUnary() (expr, error) {
+	if p.accept("-") || p.accept("!") {
+		op := p.tokens[p.pos-1].text
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return unaryExpr{op: op, operand: operand}, nil
+	}
+	return p.parsePostfix()
+}
+
+func (p *parser) parsePostfix() (expr, error) {
+	node, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		switch {
+		case p.accept("."):
+			t := p.next()
+			if t.kind != "ident" {
+				return nil, p.errorf(t, "expected field name after '.', got %q", t.text)
+			}
+			node = memberExpr{target: node, name: t.text}
+
+		case p.accept("["):
+			index, err := p.parseBinary(0)
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			node = indexExpr{target: node, index: index}
+
+		default:
+			return node, nil
+		}
+	}
+}
+
+func (p *parser) parsePrimary() (expr, error) {
+	t := p.next()
+	switch t.kind {
+	case "number", "string":
+		return literalExpr{value: t.value}, nil
+
+	case "ident":
+		switch t.text {
+		case "true":
+			return literalExpr{value: true}, nil
+		case "false":
+			return literalExpr{value: false}, nil
+		case "null":
+			return literalExpr{value: nil}, nil
+		}
+
+		if _, ok := exprFunctions[t.text]; !ok {
+			return nil, p.errorf(t, "unknown function %q", t.text)
+		}
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		call := callExpr{name: t.text}
+		if !p.accept(")") {
+			for {
+				arg, err := p.parseBinary(0)
+				if err != nil {
+					return nil, err
+				}
+				call.args = append(call.args, arg)
+				if p.accept(")") {
+					break
+				}
+				if err := p.expect(","); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return call, nil
+
+	case "op":
+		switch t.text {
+		case "$":
+			return rootExpr{}, nil
+		case "(":
+			node, err := p.parseBinary(0)
+			if err != nil {
+				return nil, err
+			}
+			return node, p.expect(")")
+		}
+	}
+
+	if t.kind == "eof" {
+		return nil, p.errorf(t, "unexpected end of expression")
+	}
+	return nil, p.errorf(t, "unexpected %q", t.text)
+}
+
+// --- Вычисление ---
+
+func (n literalExpr) eval(*exprEnv) (interface{}, error) { return n.value, nil }
+
+func (rootExpr) eval(env *exprEnv) (interface{}, error) { return env.state, nil }
+
+func (n memberExpr) eval(env *exprEnv) (interface{}, error) {
+	value, _, err := evalPath(n, env)
+	return value, err
+}
+
+func (n indexExpr) eval(env *exprEnv) (interface{}, error) {
+	value, _, err := evalPath(n, env)
+	return value, err
+}
+
+// evalPath вычисляет цепочку обращений к полям и индексам; found = false, если чего-то нет по пути
+func evalPath(node expr, env *exprEnv) (interface{}, bool, error) {
+	switch n := node.(type) {
+	case memberExpr:
+		target, found, err := evalPath(n.target, env)
+		if err != nil || !found {
+			return nil, false, err
+		}
+		value, ok := valuepath.Member(target, n.name)
+		return value, ok, nil
+
+	case indexExpr:
+		target, found, err := evalPath(n.target, env)
+		if err != nil || !found {
+			return nil, false, err
+		}
+		index, err := n.index.eval(env)
+		if err != nil {
+			return nil, false, err
+		}
+		if key, ok := index.(string); ok {
+			value, ok := valuepath.Member(target, key)
+			return value, ok, nil
+		}
+		i, ok := toFloat(index)
+		if !ok {
+			return nil, false, fmt.Errorf("index must be a number, got %T", index)
+		}
+		value, ok := valuepath.Index(target, int(i))
+		return value, ok, nil
+
+	default:
+		value, err := node.eval(env)
+		return value, err == nil, err
+	}
+}
+
+func (n unaryExpr) eval(env *exprEnv) (interface{}, error) {
+	value, err := n.operand.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.op == "!" {
+		return !truthy(value), nil
+	}
+	f, ok := toFloat(value)
+	if !ok {
+		return nil, fmt.Errorf("cannot negate %T", value)
+	}
+	return -f, nil
+}
+
+func (n binaryExpr) eval(env *exprEnv) (interface{}, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	// Операторы с коротким замыканием
+	switch n.op {
+	case "??":
+		if !isNil(left) {
+			return left, nil
+		}
+		return n.right.eval(env)
+	case "&&":
+		if !truthy(left) {
+			return false, nil
+		}
+		right, err := n.right.eval(env)
+		return truthy(right), err
+	case "||":
+		if truthy(left) {
+			return true, nil
+		}
+		right, err := n.right.eval(env)
+		return truthy(right), err
+	}
+
+	right, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return valuesEqual(left, right), nil
+	case "!=":
+		return !valuesEqual(left, right), nil
+	case "<", "<=", ">", ">=":
+		cmp, err := compareValues(left, right)
+		if err != nil {
+			return nil, err
+		}
+		switch n.op {
+		case "<":
+			return cmp < 0, nil
+		case "<=":
+			return cmp <= 0, nil
+		case ">":
+			return cmp > 0, nil
+		default:
+			return cmp >= 0, nil
+		}
+	}
+
+	// + со строкой - конкатенация
+	if n.op == "+" {
+		_, ls := left.(string)
+		_, rs := right.(string)
+		if ls || rs {
+			return stringify(left) + stringify(right), nil
+		}
+	}
+
+	l, lok := toFloat(left)
+	r, rok := toFloat(right)
+	if !lok || !rok {
+		return nil, fmt.Errorf("operator %s expects numbers, got %T and %T", n.op, left, right)
+	}
+	switch n.op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return l / r, nil
+	default:
+		if r == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return math.Mod(l, r), nil
+	}
+}
+
+func (n callExpr) eval(env *exprEnv) (interface{}, error) {
+	args := make([]interface{}, len(n.args))
+	for i, arg := range n.args {
+		value, err := arg.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = value
+	}
+
+	value, err := exprFunctions[n.name](env, args)
+	if err != nil {
+		return nil, fmt.Errorf("%s(): %w", n.name, err)
+	}
+	return value, nil
+}
+
+// exprFunctions - функции, доступные в выражениях
+var exprFunctions = map[string]func(env *exprEnv, args []interface{}) (interface{}, error){
+	"now": func(env *exprEnv, args []interface{}) (interface{}, error) {
+		return env.now.UTC().Format(time.RFC3339), nil
+	},
+	"lower": func(env *exprEnv, args []interface{}) (interface{}, error) {
+		s, err := stringArg(args)
+		return strings.ToLower(s), err
+	},
+	"upper": func(env *exprEnv, args []interface{}) (interface{}, error) {
+		s, err := stringArg(args)
+		return strings.ToUpper(s), err
+	},
+	"trim": func(env *exprEnv, args []interface{}) (interface{}, error) {
+		s, err := stringArg(args)
+		return strings.TrimSpace(s), err
+	},
+	"concat": func(env *exprEnv, args []interface{}) (interface{}, error) {
+		var b strings.Builder
+		for _, arg := range args {
+			b.WriteString(stringify(arg))
+		}
+		return b.String(), nil
+	},
+	"coalesce": func(env *exprEnv, args []interface{}) (interface{}, error) {
+		for _, arg := range args {
+			if !isNil(arg) && arg != "" {
+				return arg, nil
+			}
+		}
+		return nil, nil
+	},
+	"contains": func(env *exprEnv, args []interface{}) (interface{}, error) {
+		if len(args) != 2 {
+			return nil, fmt.Errorf("expects 2 arguments")
+		}
+		if s, ok := args[0].(string); ok {
+			return strings.Contains(s, stringify(args[1])), nil
+		}
+		list := reflect.ValueOf(args[0])
+		if list.Kind() != reflect.Slice && list.Kind() != reflect.Array {
+			return false, nil
+		}
+		for i := 0; i < list.Len(); i++ {
+			if valuesEqual(list.Index(i).Interface(), args[1]) {
+				return true, nil
+			}
+		}
+		return false, nil
+	},
+	"len": func(env *exprEnv, args []interface{}) (interface{}, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("expects 1 argument")
+		}
+		if isNil(args[0]) {
+			return float64(0), nil
+		}
+		v := reflect.ValueOf(args[0])
+		switch v.Kind() {
+		case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+			return float64(v.Len()), nil
+		}
+		return nil, fmt.Errorf("unsupported type %T", args[0])
+	},
+	"string": func(env *exprEnv, args []interface{}) (interface{}, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("expects 1 argument")
+		}
+		return stringify(args[0]), nil
+	},
+	"number": func(env *exprEnv, args []interface{}) (interface{}, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("expects 1 argument")
+		}
+		if f, ok := toFloat(args[0]); ok {
+			return f, nil
+		}
+		return strconv.ParseFloat(strings.TrimSpace(stringify(args[0])), 64)
+	},
+}
+
+func stringArg(args []interface{}) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("expects 1 argument")
+	}
+	return stringify(args[0]), nil
+}
+
+// stringify приводит значение к строке для шаблонов и конкатенации
+func stringify(v interface{}) string {
+	if isNil(v) {
+		return ""
+	}
+	switch val := v.(type) {
+	case string:
+		return val
+	case bool:
+		return strconv.FormatBool(val)
+	}
+	if f, ok := toFloat(v); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// truthy - значение в логическом контексте: null, false, 0, "" и пустые списки ложны
+func truthy(v interface{}) bool {
+	if isNil(v) {
+		return false
+	}
+	switch val := v.(type) {
+	case bool:
+		return val
+	case string:
+		return val != ""
+	}
+	if f, ok := toFloat(v); ok {
+		return f != 0
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return rv.Len() > 0
+	}
+	return true
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
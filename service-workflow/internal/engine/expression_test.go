@@ -0,0 +1,263 @@
+package engine
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testEnv() *exprEnv {
+	return &exprEnv{
+		state: map[string]interface{}{
+			"input": map[string]interface{}{
+				"Message": "hello",
+				"count":   float64(3),
+			},
+			"tickets": []interface{}{
+				map[string]interface{}{"Id": "t-1"},
+				map[string]interface{}{"Id": "t-2"},
+			},
+			"клиент": map[string]interface{}{"имя": "Анна"},
+			"agent":  nil,
+			"empty":  "",
+		},
+		now: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+}
+
+func evalExpression(t *testing.T, source string) (interface{}, error) {
+	t.Helper()
+	node, err := parseExpression(source)
+	if err != nil {
+		return nil, err
+	}
+	return node.eval(testEnv())
+}
+
+func TestExpressionPrecedence(t *testing.T) {
+	tests := []struct {
+		source string
+		want   interface{}
+	}{
+		{"1 + 2 * 3", float64(7)},
+		{"(1 + 2) * 3", float64(9)},
+		{"10 - 4 - 3", float64(3)},
+		{"7 % 4 + 1", float64(4)},
+		{"-2 * 3", float64(-6)},
+		{"1 + 2 == 3", true},
+		{"1 < 2 && 2 < 1", false},
+		{"false || 1 < 2 && true", true},
+		{"!true || true", true},
+		{"!(true || true)", false},
+		{"null ?? 1 + 1", float64(2)},
+		{"$.agent ?? 'nobody' == 'nobody'", true}, // ?? связывает слабее ==
+		{"($.agent ?? 'nobody') == 'nobody'", true},
+		{"'a' + 1", "a1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			got, err := evalExpression(t, tt.source)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpressionIndexing(t *testing.T) {
+	tests := []struct {
+		source string
+		want   interface{}
+	}{
+		{"$.tickets[0].Id", "t-1"},
+		{"$.tickets[-1].Id", "t-2"},
+		{"$.tickets[$.input.count - 2].Id", "t-2"},
+		{"$.input['Message']", "hello"},
+		{"$.tickets[5]", nil},
+		{"$.missing.deep", nil},
+		{"$.клиент.имя", "Анна"},
+		{"upper($.клиент.имя)", "АННА"},
+		{"len($.tickets)", float64(2)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			got, err := evalExpression(t, tt.source)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpressionCoalesce(t *testing.T) {
+	tests := []struct {
+		source string
+		want   interface{}
+	}{
+		{"coalesce($.agent, 'nobody')", "nobody"},
+		{"coalesce($.missing, $.agent, $.input.Message)", "hello"},
+		{"coalesce($.empty, 'x')", "x"}, // пустая строка пропускается
+		{"coalesce($.agent)", nil},
+		{"$.missing ?? $.agent ?? 'last'", "last"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			got, err := evalExpression(t, tt.source)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	tests := []struct {
+		template string
+		want     interface{}
+	}{
+		{"Тикет {{ $.tickets[0].Id }} закрыт", "Тикет t-1 закрыт"},
+		{"{{ $.input.count }}", float64(3)},
+		{"{{ $.input.count }} шт.", "3 шт."},
+		{"{{$.input.Message}}, {{ $.клиент.имя }}!", "hello, Анна!"},
+		{"no expressions", "no expressions"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			got, err := evaluateTemplate(tt.template, testEnv())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := evaluateTemplate("Тикет {{ $.tickets[0].Id", testEnv()); err == nil {
+		t.Error("expected error for unclosed {{")
+	}
+}
+
+func TestExpressionErrorPositions(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"1 +", "unexpected end of expression at position 4"},
+		{"1 + * 2", `unexpected "*" at position 5`},
+		{"(1 + 2", `expected ")", got end of expression at position 7`},
+		{"$.a[1", `expected "]", got end of expression at position 6`},
+		{"foo(1)", `unknown function "foo" at position 1`},
+		{"$.клиент.имя # 1", `unexpected character '#' at position 14`},
+		{"'abc", "unterminated string at position 1"},
+		{"1 2", `unexpected "2" at position 3`},
+		{"$.", `expected field name after '.', got "" at position 3`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			_, err := parseExpression(tt.source)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %q does not contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpressionEvalErrors(t *testing.T) {
+	for _, source := range []string{"1 / 0", "'a' - 1", "$.tickets[true]"} {
+		t.Run(source, func(t *testing.T) {
+			if _, err := evalExpression(t, source); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestResolveValueLiterals(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  interface{}
+	}{
+		{"top-level reference", `"$.input.Message"`, "hello"},
+		{"nested reference is literal", `{"text": "$.input.Message"}`, map[string]interface{}{"text": "$.input.Message"}},
+		{"equals sign is literal", `"=1+1"`, "=1+1"},
+		{"braces are literal", `"Hi {{ name }}"`, "Hi {{ name }}"},
+		{"wrapped expression", `{"$expr": "$.input.count * 2"}`, float64(6)},
+		{"nested wrapped template", `{"text": {"$template": "Hi {{ $.клиент.имя }}"}}`, map[string]interface{}{"text": "Hi Анна"}},
+		{"wrapper in list", `[{"$expr": "len($.tickets)"}, "$.x"]`, []interface{}{float64(2), "$.x"}},
+		{"object with extra keys is literal", `{"$expr": "1", "other": 2}`, map[string]interface{}{"$expr": "1", "other": float64(2)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value interface{}
+			if err := json.Unmarshal([]byte(tt.value), &value); err != nil {
+				t.Fatal(err)
+			}
+			got, err := resolveValue(value, testEnv(), true)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := resolveValue("$.missing", testEnv(), true); err == nil {
+		t.Error("expected error for missing top-level reference")
+	}
+	if _, err := resolveValue(map[string]interface{}{"$expr": float64(1)}, testEnv(), true); err == nil {
+		t.Error("expected error for non-string $expr")
+	}
+}
+
+func TestValueRefs(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []string
+	}{
+		{`"$.ticket.Id"`, []string{"ticket"}},
+		{`"$.tickets[0].Id"`, []string{"tickets"}},
+		{`{"a": "$.ticket.Id"}`, nil},
+		{`"literal {{ $.x }}"`, nil},
+		{`{"a": {"$expr": "coalesce($.agent, $.input.Message)"}}`, []string{"agent", "input"}},
+		{`[{"$template": "{{ $.a }}-{{ $.b[$.c] }}"}]`, []string{"a", "b", "c"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			var value interface{}
+			if err := json.Unmarshal([]byte(tt.value), &value); err != nil {
+				t.Fatal(err)
+			}
+			got, err := ValueRefs(value)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
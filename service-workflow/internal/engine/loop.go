@@ -0,0 +1,190 @@
+package engine
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// LoopLimitErrorType - тип ошибки, если while не завершился за maxIterations итераций
+const LoopLimitErrorType = "LoopLimitExceeded"
+
+// defaultMaxIterations ограничивает while, если maxIterations не задан
+const defaultMaxIterations = 100
+
+// Iterator описывает тело цикла foreach/while - вложенный список состояний
+type Iterator struct {
+	StartAt string            `json:"startAt,omitempty"`
+	States  []StateDefinition `json:"states"`
+	Result  string            `json:"result,omitempty"` // Ссылка "$.key" на результат итерации, по умолчанию - все записанные итерацией ключи
+}
+
+// executeForeach выполняет iterator для каждого элемента списка по ссылке itemsPath.
+// Итерации работают с копией state, в которой доступны $.item и $.index;
+// одновременно выполняется не больше maxConcurrency итераций (по умолчанию по одной).
+func (e *WorkflowEngine) executeForeach(ctx workflow.Context, r *run, def StateDefinition, state map[string]interface{}) error {
+	logger := workflow.GetLogger(ctx)
+
+	if def.Iterator == nil {
+		return fmt.Errorf("foreach state %s has no iterator", def.Name)
+	}
+
+	items, err := resolveItems(def.ItemsPath, state)
+	if err != nil {
+		return fmt.Errorf("foreach state %s: %w", def.Name, err)
+	}
+
+	limit := def.MaxConcurrency
+	if limit <= 0 {
+		limit = 1
+	}
+
+	iterCtx, cancel := workflow.WithCancel(ctx)
+	defer cancel()
+
+	done := workflow.NewBufferedChannel(ctx, len(items))
+	results := make([]interface{}, len(items))
+	errs := make([]error, len(items))
+
+	start := func(i int) {
+		iterState := copyState(state)
+		iterState["item"] = items[i]
+		iterState["index"] = i
+
+		workflow.Go(iterCtx, func(ctx workflow.Context) {
+			errs[i] = e.runStates(ctx, r, def.Iterator.States, def.Iterator.StartAt, iterState)
+			if errs[i] == nil {
+				results[i] = iterationResult(def.Iterator, state, iterState)
+			}
+			done.Send(ctx, i)
+		})
+	}
+
+	started := 0
+	for ; started < len(items) && started < limit; started++ {
+		start(started)
+	}
+
+	for completed := 0; completed < len(items); completed++ {
+		var i int
+		done.Receive(ctx, &i)
+
+		if errs[i] != nil {
+			return fmt.Errorf("iteration %d failed: %w", i, errs[i])
+		}
+
+		if started < len(items) {
+			start(started)
+			started++
+		}
+	}
+
+	if def.Output != "" {
+		state[def.Output] = results
+	}
+
+	logger.Info("Foreach state completed", "name", def.Name, "items", len(items))
+	return nil
+}
+
+// executeWhile выполняет iterator, пока condition истинно, но не больше maxIterations раз.
+// В отличие от foreach итерации работают с общим state, чтобы тело цикла могло влиять на условие.
+func (e *WorkflowEngine) executeWhile(ctx workflow.Context, r *run, def StateDefinition, state map[string]interface{}) error {
+	logger := workflow.GetLogger(ctx)
+
+	if def.Iterator == nil {
+		return fmt.Errorf("while state %s has no iterator", def.Name)
+	}
+	if def.Condition == nil {
+		return fmt.Errorf("while state %s has no condition", def.Name)
+	}
+
+	// Неположительное значение валидатор не пропускает; в конфигурациях, сохраненных до проверки, оно означает значение по умолчанию
+	limit := defaultMaxIterations
+	if def.MaxIterations != nil && *def.MaxIterations > 0 {
+		limit = *def.MaxIterations
+	}
+
+	// $.index виден только внутри цикла
+	prevIndex, hadIndex := state["index"]
+	defer func() {
+		if hadIndex {
+			state["index"] = prevIndex
+		} else {
+			delete(state, "index")
+		}
+	}()
+
+	var results []interface{}
+	for i := 0; ; i++ {
+		ok, err := evaluateCondition(ctx, *def.Condition, state)
+		if err != nil {
+			return fmt.Errorf("while state %s: %w", def.Name, err)
+		}
+		if !ok {
+			break
+		}
+		if i >= limit {
+			return temporal.NewNonRetryableApplicationError(
+				fmt.Sprintf("state %s: loop did not finish within %d iterations", def.Name, limit), LoopLimitErrorType, nil)
+		}
+
+		before := copyState(state)
+		state["index"] = i
+		if err := e.runStates(ctx, r, def.Iterator.States, def.Iterator.StartAt, state); err != nil {
+			return fmt.Errorf("iteration %d failed: %w", i, err)
+		}
+		results = append(results, iterationResult(def.Iterator, before, state))
+	}
+
+	if def.Output != "" {
+		state[def.Output] = results
+	}
+
+	logger.Info("While state completed", "name", def.Name, "iterations", len(results))
+	return nil
+}
+
+// resolveItems возвращает элементы списка по ссылке "$.key"
+func resolveItems(path string, state map[string]interface{}) ([]interface{}, error) {
+	if !strings.HasPrefix(path, "$.") {
+		return nil, fmt.Errorf("itemsPath must be a $. reference, got %q", path)
+	}
+
+	value, ok := getNestedValue(state, path[2:])
+	if !ok {
+		return nil, fmt.Errorf("state key not found: %s", path[2:])
+	}
+	if isNil(value) {
+		return nil, nil
+	}
+
+	// Списки бывают []interface{} после JSON и типизированными слайсами из proto
+	list := reflect.ValueOf(value)
+	if list.Kind() != reflect.Slice && list.Kind() != reflect.Array {
+		return nil, fmt.Errorf("%s is not a list", path)
+	}
+
+	items := make([]interface{}, list.Len())
+	for i := range items {
+		items[i] = list.Index(i).Interface()
+	}
+	return items, nil
+}
+
+// iterationResult возвращает результат итерации: значение по ссылке result
+// или ключи, записанные итерацией (без $.item и $.index)
+func iterationResult(it *Iterator, base, iterState map[string]interface{}) interface{} {
+	if strings.HasPrefix(it.Result, "$.") {
+		value, _ := getNestedValue(iterState, it.Result[2:])
+		return value
+	}
+
+	result := changedKeys(base, iterState)
+	delete(result, "item")
+	delete(result, "index")
+	return result
+}
@@ -0,0 +1,83 @@
+package engine
+
+import (
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// RetryPolicy описывает политику повторов activity, маппится на temporal.RetryPolicy
+type RetryPolicy struct {
+	InitialInterval        string   `json:"initialInterval,omitempty"` // Например: "1s"
+	BackoffCoefficient     float64  `json:"backoffCoefficient,omitempty"`
+	MaximumInterval        string   `json:"maximumInterval,omitempty"`
+	MaximumAttempts        int32    `json:"maximumAttempts,omitempty"` // 0 - без ограничений
+	NonRetryableErrorTypes []string `json:"nonRetryableErrorTypes,omitempty"`
+}
+
+// defaultActivityOptions возвращает опции activity на уровне workflow:
+// значения по умолчанию, переопределенные таймаутами и retry из определения
+func defaultActivityOptions(def WorkflowDefinition) workflow.ActivityOptions {
+	options := workflow.ActivityOptions{
+		StartToCloseTimeout:    time.Minute * 5,  // 5 минут на выполнение активности
+		ScheduleToCloseTimeout: time.Minute * 10, // 10 минут от планирования до завершения
+	}
+
+	applyTimeouts(&options, def.Timeouts)
+	applyRetryPolicy(&options, def.Retry)
+
+	return options
+}
+
+// stateActivityOptions накладывает таймауты и retry состояния поверх опций workflow
+func stateActivityOptions(ctx workflow.Context, def StateDefinition) workflow.ActivityOptions {
+	options := workflow.GetActivityOptions(ctx)
+
+	applyTimeouts(&options, def.Timeouts)
+	applyRetryPolicy(&options, def.Retry)
+
+	return options
+}
+
+func applyTimeouts(options *workflow.ActivityOptions, timeouts Timeouts) {
+	if d := parseDuration(timeouts.StartToClose); d > 0 {
+		options.StartToCloseTimeout = d
+	}
+	if d := parseDuration(timeouts.ScheduleToClose); d > 0 {
+		options.ScheduleToCloseTimeout = d
+	}
+	if d := parseDuration(timeouts.Heartbeat); d > 0 {
+		options.HeartbeatTimeout = d
+	}
+}
+
+// applyRetryPolicy переопределяет только заданные поля, остальные наследуются от уровня выше
+func applyRetryPolicy(options *workflow.ActivityOptions, retry *RetryPolicy) {
+	if retry == nil {
+		return
+	}
+
+	policy := &temporal.RetryPolicy{}
+	if options.RetryPolicy != nil {
+		*policy = *options.RetryPolicy
+	}
+
+	if d := parseDuration(retry.InitialInterval); d > 0 {
+		policy.InitialInterval = d
+	}
+	if retry.BackoffCoefficient > 0 {
+		policy.BackoffCoefficient = retry.BackoffCoefficient
+	}
+	if d := parseDuration(retry.MaximumInterval); d > 0 {
+		policy.MaximumInterval = d
+	}
+	if retry.MaximumAttempts > 0 {
+		policy.MaximumAttempts = retry.MaximumAttempts
+	}
+	if len(retry.NonRetryableErrorTypes) > 0 {
+		policy.NonRetryableErrorTypes = retry.NonRetryableErrorTypes
+	}
+
+	options.RetryPolicy = policy
+}
@@ -0,0 +1,201 @@
+package engine
+
+import (
+	"fmt"
+	"reflect"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// Branch описывает ветку parallel-состояния.
+// Результаты ветки попадают в state под ключом Name (изолированное пространство имен).
+type Branch struct {
+	Name    string            `json:"name"`
+	StartAt string            `json:"startAt,omitempty"`
+	States  []StateDefinition `json:"states"`
+}
+
+type branchResult struct {
+	state map[string]interface{}
+	err   error
+}
+
+// branchCompensationsChangeID - маркер версии кода, начиная с которой компенсации собираются по веткам
+const branchCompensationsChangeID = "branch-compensations"
+
+// cancelWaitsChangeID - маркер версии кода, начиная с которой ожидания сигналов завершаются при отмене контекста
+const cancelWaitsChangeID = "cancel-waits"
+
+// watchCancel добавляет в selector отмену ctx, чтобы ожидание отмененной ветки parallel завершилось.
+// Возвращает false для выполнений, начатых раньше: их ожидания отмену не замечают, как при записи истории.
+func watchCancel(ctx workflow.Context, selector workflow.Selector) bool {
+	if workflow.GetVersion(ctx, cancelWaitsChangeID, workflow.DefaultVersion, 1) == workflow.DefaultVersion {
+		return false
+	}
+	selector.AddReceive(ctx.Done(), func(c workflow.ReceiveChannel, more bool) {})
+	return true
+}
+
+// branchCompensationsKey - ключ контекста, под которым ветка parallel собирает компенсации своих шагов
+type branchCompensationsKey struct{}
+
+// addCompensation запоминает компенсацию выполненного шага: в ветке parallel - среди компенсаций ветки,
+// иначе - среди компенсаций выполнения
+func (r *run) addCompensation(ctx workflow.Context, compensation StateDefinition) {
+	if branch, ok := ctx.Value(branchCompensationsKey{}).(*[]StateDefinition); ok {
+		*branch = append(*branch, compensation)
+		return
+	}
+	r.compensations = append(r.compensations, compensation)
+}
+
+// executeParallel запускает ветки одновременно и дожидается их по условию join:
+// all - все ветки, any - первая успешная, first - первые joinCount успешных.
+// Незавершенные ветки отменяются, при join = all первая ошибка отменяет остальные.
+// Компенсации переходят к выполнению только от успешных веток, учтенных в join;
+// шаги остальных веток откатываются после того, как завершатся все отмененные ветки.
+func (e *WorkflowEngine) executeParallel(ctx workflow.Context, r *run, def StateDefinition, state map[string]interface{}) error {
+	logger := workflow.GetLogger(ctx)
+
+	need, err := joinCount(def)
+	if err != nil {
+		return err
+	}
+
+	// Выполнения, начатые раньше, копят компенсации веток в общем списке или не дожидаются отмененных веток,
+	// как при записи их истории
+	version := workflow.GetVersion(ctx, branchCompensationsChangeID, workflow.DefaultVersion, 2)
+	perBranch := version != workflow.DefaultVersion
+	drain := version >= 2
+
+	branchCtx, cancel := workflow.WithCancel(ctx)
+	defer cancel()
+
+	// Буферизованный канал, чтобы отмененные ветки не блокировались на отправке
+	done := workflow.NewBufferedChannel(ctx, len(def.Branches))
+	results := make([]branchResult, len(def.Branches))
+	compensations := make([][]StateDefinition, len(def.Branches))
+	finished := make([]bool, len(def.Branches))
+	settled := false // Ветки, которые завершатся позже, в результат уже не попадут
+
+	for i, branch := range def.Branches {
+		i, branch := i, branch
+		branchState := copyState(state)
+
+		workflow.Go(branchCtx, func(ctx workflow.Context) {
+			if perBranch {
+				ctx = workflow.WithValue(ctx, branchCompensationsKey{}, &compensations[i])
+			}
+			err := e.runStates(ctx, r, branch.States, branch.StartAt, branchState)
+			results[i] = branchResult{state: branchState, err: err}
+			finished[i] = true
+			if settled && !drain {
+				e.compensate(ctx, compensations[i], branchState)
+			}
+			done.Send(ctx, i)
+		})
+	}
+
+	var (
+		succeeded []int
+		failure   error
+		completed int
+	)
+	for completed < len(def.Branches) && len(succeeded) < need {
+		var i int
+		done.Receive(ctx, &i)
+		completed++
+
+		if results[i].err != nil {
+			logger.Warn("Parallel branch failed", "name", def.Name, "branch", def.Branches[i].Name, "error", results[i].err)
+			if def.Join == "" || def.Join == "all" {
+				failure = fmt.Errorf("branch %s failed: %w", def.Branches[i].Name, results[i].err)
+				break
+			}
+			continue
+		}
+		succeeded = append(succeeded, i)
+	}
+	if failure == nil && len(succeeded) < need {
+		failure = fmt.Errorf("only %d of %d required branches succeeded", len(succeeded), need)
+	}
+
+	// Компенсации учтенных веток переходят к выполнению, шаги остальных откатываются
+	kept := make([]bool, len(def.Branches))
+	for _, i := range succeeded {
+		kept[i] = true
+		for _, compensation := range compensations[i] {
+			r.addCompensation(ctx, compensation)
+		}
+	}
+	settled = true
+	cancel()
+
+	// Отмененные ветки дожидаются, чтобы откатить и шаги, которые они успеют выполнить до отмены:
+	// после завершения workflow их компенсации уже не запустятся
+	if drain {
+		for ; completed < len(def.Branches); completed++ {
+			done.Receive(ctx, nil)
+		}
+	}
+
+	var discarded []int
+	for i := range def.Branches {
+		if !kept[i] && finished[i] {
+			discarded = append(discarded, i)
+		}
+	}
+	for _, i := range discarded {
+		e.compensate(ctx, compensations[i], results[i].state)
+	}
+
+	if failure != nil {
+		return failure
+	}
+
+	// Переносим записанные веткой ключи в state под именем ветки
+	for _, i := range succeeded {
+		state[def.Branches[i].Name] = changedKeys(state, results[i].state)
+	}
+
+	logger.Info("Parallel state completed", "name", def.Name, "succeeded", len(succeeded))
+	return nil
+}
+
+// joinCount возвращает число успешных веток, которых нужно дождаться
+func joinCount(def StateDefinition) (int, error) {
+	switch def.Join {
+	case "", "all":
+		return len(def.Branches), nil
+	case "any":
+		return 1, nil
+	case "first":
+		if def.JoinCount <= 0 || def.JoinCount > len(def.Branches) {
+			return 0, fmt.Errorf("joinCount must be between 1 and %d", len(def.Branches))
+		}
+		return def.JoinCount, nil
+	default:
+		return 0, fmt.Errorf("unknown join mode: %s", def.Join)
+	}
+}
+
+// copyState возвращает поверхностную копию state для изолированного выполнения
+func copyState(state map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(state))
+	for k, v := range state {
+		result[k] = v
+	}
+	return result
+}
+
+// changedKeys возвращает ключи, которые были добавлены или изменены относительно base
+func changedKeys(base, updated map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{})
+	for k, v := range updated {
+		if old, ok := base[k]; ok && reflect.DeepEqual(old, v) {
+			continue
+		}
+		result[k] = v
+	}
+	return result
+}
@@ -0,0 +1,59 @@
+package engine
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/aimustaev/service-workflow/internal/generated/proto"
+	"github.com/aimustaev/service-workflow/internal/model"
+)
+
+// payloadTypes - типы, в которые можно декодировать payload сигналов (поле payloadType в определении)
+var (
+	payloadTypes      = map[string]reflect.Type{}
+	payloadTypesMutex sync.RWMutex
+)
+
+func init() {
+	RegisterPayloadType("Message", model.Message{})
+	RegisterPayloadType("Assignment", model.Assignment{})
+	RegisterPayloadType("Ticket", &proto.TicketResponse{})
+}
+
+// RegisterPayloadType registers a type that signal payloads can be decoded into
+func RegisterPayloadType(name string, sample interface{}) {
+	payloadTypesMutex.Lock()
+	defer payloadTypesMutex.Unlock()
+	payloadTypes[name] = reflect.TypeOf(sample)
+}
+
+// PayloadTypes returns the names of all registered payload types
+func PayloadTypes() []string {
+	payloadTypesMutex.RLock()
+	defer payloadTypesMutex.RUnlock()
+
+	names := make([]string, 0, len(payloadTypes))
+	for name := range payloadTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newPayload возвращает указатель для декодирования payload; пустой тип - map[string]interface{}
+func newPayload(typeName string) (reflect.Value, error) {
+	if typeName == "" {
+		return reflect.New(reflect.TypeOf(map[string]interface{}{})), nil
+	}
+
+	payloadTypesMutex.RLock()
+	t, ok := payloadTypes[typeName]
+	payloadTypesMutex.RUnlock()
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("unknown payload type: %s", typeName)
+	}
+
+	return reflect.New(t), nil
+}
@@ -0,0 +1,103 @@
+package engine
+
+import (
+	"encoding/json"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+	"google.golang.org/protobuf/encoding/protojson"
+	protov2 "google.golang.org/protobuf/proto"
+)
+
+// Имена query handlers, которые регистрирует движок
+const (
+	QueryCurrentState = "currentState"
+	QueryState        = "state"
+	QueryHistory      = "history"
+)
+
+// StateVisit описывает посещение состояния в истории выполнения
+type StateVisit struct {
+	Name      string     `json:"name"`
+	Type      string     `json:"type"`
+	StartedAt time.Time  `json:"startedAt"`
+	EndedAt   *time.Time `json:"endedAt,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// executionTracker хранит текущее состояние и историю посещений для query handlers
+type executionTracker struct {
+	current string
+	history []StateVisit
+}
+
+// registerQueries регистрирует query handlers currentState, state и history
+func registerQueries(ctx workflow.Context, tracker *executionTracker, state map[string]interface{}) error {
+	err := workflow.SetQueryHandler(ctx, QueryCurrentState, func() (string, error) {
+		return tracker.current, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = workflow.SetQueryHandler(ctx, QueryState, func() (map[string]json.RawMessage, error) {
+		return serializeState(state)
+	})
+	if err != nil {
+		return err
+	}
+
+	return workflow.SetQueryHandler(ctx, QueryHistory, func() ([]StateVisit, error) {
+		return tracker.history, nil
+	})
+}
+
+// enter отмечает начало выполнения состояния и возвращает индекс записи в истории
+func (t *executionTracker) enter(ctx workflow.Context, def StateDefinition) int {
+	t.current = def.Name
+	t.history = append(t.history, StateVisit{
+		Name:      def.Name,
+		Type:      def.Type,
+		StartedAt: workflow.Now(ctx),
+	})
+	return len(t.history) - 1
+}
+
+// exit отмечает завершение состояния; индекс нужен, т.к. ветки parallel выполняются одновременно
+func (t *executionTracker) exit(ctx workflow.Context, visit int, err error) {
+	if visit < 0 || visit >= len(t.history) {
+		return
+	}
+
+	now := workflow.Now(ctx)
+	entry := &t.history[visit]
+	entry.EndedAt = &now
+	if err != nil {
+		entry.Error = err.Error()
+	}
+}
+
+// serializeState сериализует state map в JSON, protobuf-сообщения - через protojson
+func serializeState(state map[string]interface{}) (map[string]json.RawMessage, error) {
+	result := make(map[string]json.RawMessage, len(state))
+
+	for key, value := range state {
+		var (
+			data []byte
+			err  error
+		)
+
+		if msg, ok := value.(protov2.Message); ok && !isNil(value) {
+			data, err = protojson.Marshal(msg)
+		} else {
+			data, err = json.Marshal(value)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		result[key] = data
+	}
+
+	return result, nil
+}
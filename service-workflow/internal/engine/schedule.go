@@ -0,0 +1,50 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+
+	enumspb "go.temporal.io/api/enums/v1"
+)
+
+// Schedule describes a Temporal Schedule that starts the workflow by cron instead of a message.
+// Schedules are created, updated and deleted by manager_workflow.ConfigManager when configs are activated or deactivated.
+type Schedule struct {
+	Cron     string          `json:"cron"`               // Cron-выражение, например "0 3 * * *"
+	TimeZone string          `json:"timeZone,omitempty"` // IANA, по умолчанию UTC
+	Jitter   string          `json:"jitter,omitempty"`   // Случайная задержка каждого запуска, например "5m"
+	Overlap  string          `json:"overlap,omitempty"`  // Если предыдущий запуск еще идет: skip (по умолчанию), bufferOne, bufferAll, cancelOther, terminateOther, allowAll
+	Input    json.RawMessage `json:"input,omitempty"`    // Вход каждого запуска, объект
+}
+
+// OverlapPolicy returns the Temporal overlap policy for the overlap name
+func (s Schedule) OverlapPolicy() (enumspb.ScheduleOverlapPolicy, error) {
+	switch s.Overlap {
+	case "", "skip":
+		return enumspb.SCHEDULE_OVERLAP_POLICY_SKIP, nil
+	case "bufferOne":
+		return enumspb.SCHEDULE_OVERLAP_POLICY_BUFFER_ONE, nil
+	case "bufferAll":
+		return enumspb.SCHEDULE_OVERLAP_POLICY_BUFFER_ALL, nil
+	case "cancelOther":
+		return enumspb.SCHEDULE_OVERLAP_POLICY_CANCEL_OTHER, nil
+	case "terminateOther":
+		return enumspb.SCHEDULE_OVERLAP_POLICY_TERMINATE_OTHER, nil
+	case "allowAll":
+		return enumspb.SCHEDULE_OVERLAP_POLICY_ALLOW_ALL, nil
+	default:
+		return enumspb.SCHEDULE_OVERLAP_POLICY_UNSPECIFIED, fmt.Errorf("unknown overlap policy: %s", s.Overlap)
+	}
+}
+
+// WorkflowInput returns the input passed to every scheduled run, an empty object when input is not set
+func (s Schedule) WorkflowInput() (map[string]interface{}, error) {
+	input := make(map[string]interface{})
+	if !hasSchema(s.Input) {
+		return input, nil
+	}
+	if err := json.Unmarshal(s.Input, &input); err != nil {
+		return nil, fmt.Errorf("schedule input must be an object: %w", err)
+	}
+	return input, nil
+}
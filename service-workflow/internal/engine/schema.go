@@ -0,0 +1,73 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/xeipuuv/gojsonschema"
+	"go.temporal.io/sdk/temporal"
+)
+
+// SchemaValidationErrorType - тип ApplicationError при несоответствии данных JSON Schema.
+// Ошибка не ретраится и может быть перехвачена через catch.errorTypes.
+const SchemaValidationErrorType = "SchemaValidationError"
+
+// compiledSchemas кэширует скомпилированные схемы по их тексту
+var compiledSchemas sync.Map
+
+// CompileSchema компилирует JSON Schema; пустая схема или null означают отсутствие схемы
+func CompileSchema(schema json.RawMessage) (*gojsonschema.Schema, error) {
+	if !hasSchema(schema) {
+		return nil, nil
+	}
+
+	key := string(schema)
+	if cached, ok := compiledSchemas.Load(key); ok {
+		return cached.(*gojsonschema.Schema), nil
+	}
+
+	compiled, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(schema))
+	if err != nil {
+		return nil, err
+	}
+
+	compiledSchemas.Store(key, compiled)
+	return compiled, nil
+}
+
+// validateSchema проверяет значение по схеме и возвращает non-retryable ошибку при несоответствии
+func validateSchema(schema json.RawMessage, value interface{}, subject string) error {
+	compiled, err := CompileSchema(schema)
+	if err != nil {
+		return temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("invalid schema for %s: %v", subject, err), SchemaValidationErrorType, err)
+	}
+	if compiled == nil {
+		return nil
+	}
+
+	result, err := compiled.Validate(gojsonschema.NewGoLoader(value))
+	if err != nil {
+		return temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("failed to validate %s: %v", subject, err), SchemaValidationErrorType, err)
+	}
+	if result.Valid() {
+		return nil
+	}
+
+	problems := make([]string, 0, len(result.Errors()))
+	for _, e := range result.Errors() {
+		problems = append(problems, e.String())
+	}
+
+	return temporal.NewNonRetryableApplicationError(
+		fmt.Sprintf("%s does not match schema: %s", subject, strings.Join(problems, "; ")),
+		SchemaValidationErrorType, nil, problems)
+}
+
+func hasSchema(schema json.RawMessage) bool {
+	trimmed := strings.TrimSpace(string(schema))
+	return trimmed != "" && trimmed != "null"
+}
@@ -0,0 +1,197 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// SelectEvent описывает событие select-состояния: сигнал или таймер. Срабатывает событие, которое произошло первым.
+type SelectEvent struct {
+	Name             string            `json:"name"`                       // Имя события, записывается в state при срабатывании
+	Signal           string            `json:"signal,omitempty"`           // Имя ожидаемого сигнала
+	PayloadType      string            `json:"payloadType,omitempty"`      // Тип payload сигнала (Message, Assignment, Ticket), по умолчанию объект
+	Output           string            `json:"output,omitempty"`           // Ключ state для payload сигнала
+	Timer            string            `json:"timer,omitempty"`            // Длительность таймера, например "48h"
+	BusinessDuration string            `json:"businessDuration,omitempty"` // Длительность таймера в рабочем времени календаря
+	Calendar         string            `json:"calendar,omitempty"`         // Календарь для businessDuration
+	Next             string            `json:"next,omitempty"`             // Переход после события, по умолчанию - обычный переход состояния
+	Actions          []StateDefinition `json:"actions,omitempty"`          // Действия после срабатывания события
+}
+
+// executeSelect ждет первое из событий состояния, выполняет его действия и возвращает его переход.
+// Имя сработавшего события записывается в state по ключу output, по умолчанию - по имени состояния.
+// interruptible - ожидание можно прервать для continue-as-new, сроки таймеров переносятся.
+func (e *WorkflowEngine) executeSelect(ctx workflow.Context, r *run, def StateDefinition, state map[string]interface{}, interruptible bool) (string, error) {
+	logger := workflow.GetLogger(ctx)
+
+	// Сроки из предыдущего запуска относятся только к первому ожиданию верхнего уровня
+	var resumeDeadlines map[string]time.Time
+	if interruptible {
+		resumeDeadlines, r.resumeEventDeadlines = r.resumeEventDeadlines, nil
+	}
+
+	var (
+		fired       *SelectEvent
+		interrupted bool
+		err         error
+	)
+
+	// deliver запоминает событие сигнала и сохраняет его payload
+	deliver := func(event *SelectEvent, decode func(payload interface{}) error) {
+		fired = event
+		payload, payloadErr := newPayload(event.PayloadType)
+		if payloadErr != nil {
+			// Сигнал все равно вычитываем, чтобы не зациклиться на нем
+			_ = decode(nil)
+			err = payloadErr
+			return
+		}
+		if decodeErr := decode(payload.Interface()); decodeErr != nil {
+			err = decodeErr
+			return
+		}
+		if event.Output != "" {
+			state[event.Output] = payload.Elem().Interface()
+		}
+	}
+
+	// Сигналы, перенесенные из предыдущего запуска, срабатывают без ожидания
+	for i := range def.Events {
+		event := &def.Events[i]
+		if event.Signal == "" {
+			continue
+		}
+		if raw, ok := r.takeBuffered(event.Signal); ok {
+			deliver(event, func(payload interface{}) error {
+				if payload == nil {
+					return nil
+				}
+				return json.Unmarshal(raw, payload)
+			})
+			if err != nil {
+				return "", err
+			}
+			return e.selectFired(ctx, r, def, fired, state)
+		}
+	}
+
+	selector := workflow.NewSelector(ctx)
+
+	// Таймеры проигравших событий отменяются, как только одно из событий сработало
+	timerCtx, cancelTimers := workflow.WithCancel(ctx)
+	defer cancelTimers()
+
+	deadlines := make(map[string]time.Time)
+	for i := range def.Events {
+		event := &def.Events[i]
+
+		if event.Signal != "" {
+			selector.AddReceive(workflow.GetSignalChannel(ctx, event.Signal), func(c workflow.ReceiveChannel, more bool) {
+				deliver(event, func(payload interface{}) error {
+					c.Receive(ctx, payload)
+					return nil
+				})
+			})
+			continue
+		}
+
+		// После continue-as-new ждем только оставшееся время
+		deadline, ok := resumeDeadlines[event.Name]
+		if !ok {
+			var deadlineErr error
+			if deadline, deadlineErr = e.eventDeadline(ctx, def, *event); deadlineErr != nil {
+				return "", deadlineErr
+			}
+		}
+		deadlines[event.Name] = deadline
+
+		duration := deadline.Sub(workflow.Now(ctx))
+		if duration < 0 {
+			duration = 0
+		}
+		selector.AddFuture(workflow.NewTimer(timerCtx, duration), func(f workflow.Future) {
+			fired = event
+		})
+	}
+
+	if interruptible {
+		selector.AddReceive(r.checkpoint, func(c workflow.ReceiveChannel, more bool) {
+			c.Receive(ctx, nil)
+			interrupted = true
+		})
+	}
+
+	cancellable := watchCancel(ctx, selector)
+
+	logger.Info("Waiting for select event", "name", def.Name, "events", len(def.Events))
+	selector.Select(ctx)
+
+	// Таймеры отмененной ветки тоже срабатывают, поэтому отмену проверяем первой
+	if cancellable && ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+
+	if interrupted {
+		if len(deadlines) > 0 {
+			r.eventDeadlines = deadlines
+		}
+		return "", errCheckpoint
+	}
+	cancelTimers()
+
+	if err != nil {
+		return "", err
+	}
+
+	return e.selectFired(ctx, r, def, fired, state)
+}
+
+// selectFired записывает сработавшее событие в state и выполняет его действия
+func (e *WorkflowEngine) selectFired(ctx workflow.Context, r *run, def StateDefinition, event *SelectEvent, state map[string]interface{}) (string, error) {
+	output := def.Output
+	if output == "" {
+		output = def.Name
+	}
+	state[output] = event.Name
+
+	workflow.GetLogger(ctx).Info("Select event fired", "name", def.Name, "event", event.Name)
+
+	// В отличие от действий таймера, ошибка activity события завершает состояние и может быть обработана catch
+	for _, action := range event.Actions {
+		switch action.Type {
+		case "activity":
+			if err := e.executeActivity(ctx, action, state); err != nil {
+				return "", fmt.Errorf("event %s: %w", event.Name, err)
+			}
+		case "signal":
+			e.executeSignalHandler(ctx, r, action, state)
+		}
+	}
+
+	return event.Next, nil
+}
+
+// eventDeadline возвращает срок таймера события: timer отсчитывается по часам, businessDuration - по рабочему календарю
+func (e *WorkflowEngine) eventDeadline(ctx workflow.Context, def StateDefinition, event SelectEvent) (time.Time, error) {
+	switch {
+	case event.BusinessDuration != "":
+		deadline, err := e.businessDeadline(ctx, event.Calendar, event.BusinessDuration)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("state %s, event %s: %w", def.Name, event.Name, err)
+		}
+		return deadline, nil
+
+	case event.Timer != "":
+		duration, err := time.ParseDuration(event.Timer)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid timer for event %s of state %s: %w", event.Name, def.Name, err)
+		}
+		return workflow.Now(ctx).Add(duration), nil
+
+	default:
+		return time.Time{}, fmt.Errorf("event %s of state %s has neither signal nor timer", event.Name, def.Name)
+	}
+}
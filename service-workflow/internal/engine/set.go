@@ -0,0 +1,34 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// executeSet вычисляет values и записывает их в state без вызова activity.
+// Все значения вычисляются по state до записи, поэтому порядок ключей не важен.
+func (e *WorkflowEngine) executeSet(ctx workflow.Context, def StateDefinition, state map[string]interface{}) error {
+	keys := make([]string, 0, len(def.Values))
+	for key := range def.Values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	values := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		value, err := parseValue(ctx, def.Values[key], state)
+		if err != nil {
+			return fmt.Errorf("failed to compute %s: %w", key, err)
+		}
+		values[key] = value
+	}
+
+	for _, key := range keys {
+		state[key] = values[key]
+	}
+
+	workflow.GetLogger(ctx).Info("Set state completed", "name", def.Name, "keys", keys)
+	return nil
+}
@@ -0,0 +1,140 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/sdk/workflow"
+)
+
+// DynamicWorkflowType - тип Temporal workflow, который выполняет определения из configs.config_versions
+const DynamicWorkflowType = "DynamicTicketWorkflow"
+
+// ConfigNameMemoKey - ключ memo, в котором дочернему workflow передается имя конфигурации
+const ConfigNameMemoKey = "configName"
+
+// ChildExecution описывает запущенный дочерний workflow в режиме async
+type ChildExecution struct {
+	WorkflowID string `json:"workflowId"`
+	RunID      string `json:"runId"`
+}
+
+// executeSubworkflow запускает конфигурацию def.Workflow дочерним workflow.
+// Input - объект, значения которого могут ссылаться на state; в режиме wait результат
+// сохраняется в output и раскладывается по outputMapping, в режиме async - только данные запуска.
+func (e *WorkflowEngine) executeSubworkflow(ctx workflow.Context, def StateDefinition, state map[string]interface{}) error {
+	logger := workflow.GetLogger(ctx)
+
+	input, err := parseObjectInput(ctx, def.Input, state)
+	if err != nil {
+		return fmt.Errorf("failed to parse input: %w", err)
+	}
+
+	policy, err := parentClosePolicy(def)
+	if err != nil {
+		return err
+	}
+
+	ctx = workflow.WithChildOptions(ctx, workflow.ChildWorkflowOptions{
+		ParentClosePolicy: policy,
+		Memo:              map[string]interface{}{ConfigNameMemoKey: def.Workflow},
+	})
+
+	future := workflow.ExecuteChildWorkflow(ctx, DynamicWorkflowType, input)
+
+	var execution workflow.Execution
+	if err := future.GetChildWorkflowExecution().Get(ctx, &execution); err != nil {
+		return fmt.Errorf("failed to start subworkflow %s: %w", def.Workflow, err)
+	}
+	logger.Info("Subworkflow started", "name", def.Name, "workflow", def.Workflow, "workflowId", execution.ID)
+
+	if def.Mode == "async" {
+		if def.Output != "" {
+			state[def.Output] = ChildExecution{WorkflowID: execution.ID, RunID: execution.RunID}
+		}
+		return nil
+	}
+
+	var result interface{}
+	if err := future.Get(ctx, &result); err != nil {
+		return err
+	}
+
+	if def.Output != "" {
+		if err := validateSchema(def.OutputSchema, result, "output of "+def.Name); err != nil {
+			return err
+		}
+		state[def.Output] = result
+	}
+
+	for key, ref := range def.OutputMapping {
+		value, ok := resolveResultRef(result, ref)
+		if !ok {
+			return fmt.Errorf("subworkflow %s result has no %s", def.Workflow, ref)
+		}
+		state[key] = value
+	}
+
+	logger.Info("Subworkflow completed", "name", def.Name, "workflow", def.Workflow)
+	return nil
+}
+
+// parseObjectInput разбирает input-объект, подставляя ссылки "$.key" в значения полей
+func parseObjectInput(ctx workflow.Context, inputJSON json.RawMessage, state map[string]interface{}) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+	if len(inputJSON) == 0 {
+		return result, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(inputJSON, &fields); err != nil {
+		return nil, fmt.Errorf("subworkflow input must be an object: %w", err)
+	}
+
+	for key, raw := range fields {
+		value, err := parseValue(ctx, raw, state)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", key, err)
+		}
+		result[key] = value
+	}
+	return result, nil
+}
+
+// resolveResultRef возвращает часть результата дочернего workflow по ссылке "$" или "$.a.b"
+func resolveResultRef(result interface{}, ref string) (interface{}, bool) {
+	if ref == "$" {
+		return result, true
+	}
+	if !strings.HasPrefix(ref, "$.") {
+		return nil, false
+	}
+
+	m, ok := result.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	return getNestedValue(m, ref[2:])
+}
+
+// parentClosePolicy определяет судьбу дочернего workflow при завершении родителя.
+// По умолчанию wait-режим завершает дочерний workflow вместе с родителем, async - оставляет работать.
+func parentClosePolicy(def StateDefinition) (enumspb.ParentClosePolicy, error) {
+	switch def.ParentClosePolicy {
+	case "":
+		if def.Mode == "async" {
+			return enumspb.PARENT_CLOSE_POLICY_ABANDON, nil
+		}
+		return enumspb.PARENT_CLOSE_POLICY_TERMINATE, nil
+	case "terminate":
+		return enumspb.PARENT_CLOSE_POLICY_TERMINATE, nil
+	case "abandon":
+		return enumspb.PARENT_CLOSE_POLICY_ABANDON, nil
+	case "requestCancel":
+		return enumspb.PARENT_CLOSE_POLICY_REQUEST_CANCEL, nil
+	default:
+		return enumspb.PARENT_CLOSE_POLICY_UNSPECIFIED, fmt.Errorf("unknown parentClosePolicy: %s", def.ParentClosePolicy)
+	}
+}
@@ -0,0 +1,68 @@
+package engine
+
+import (
+	"encoding/json"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// Tracer receives state execution events. It is used by config simulation,
+// regular executions run without a tracer.
+type Tracer interface {
+	// StateStarted is called before the state runs, input is the resolved input of activity and subworkflow states.
+	// The returned function is called after the state with the state keys it has written.
+	StateStarted(ctx workflow.Context, def StateDefinition, input interface{}) func(output map[string]json.RawMessage, err error)
+}
+
+// WithTracer returns a copy of the engine that reports every executed state to tracer
+func (e *WorkflowEngine) WithTracer(tracer Tracer) *WorkflowEngine {
+	traced := *e
+	traced.tracer = tracer
+	return &traced
+}
+
+// stateTrace - начатый шаг трассировки
+type stateTrace struct {
+	before map[string]interface{} // Снимок state до выполнения, по нему вычисляется выход
+	finish func(output map[string]json.RawMessage, err error)
+}
+
+// traceStart сообщает tracer о начале состояния; без tracer возвращает nil
+func (e *WorkflowEngine) traceStart(ctx workflow.Context, def StateDefinition, state map[string]interface{}) *stateTrace {
+	if e.tracer == nil {
+		return nil
+	}
+	return &stateTrace{
+		before: copyState(state),
+		finish: e.tracer.StateStarted(ctx, def, stateInput(ctx, def, state)),
+	}
+}
+
+// end сообщает о завершении состояния и ключах state, которые оно записало
+func (t *stateTrace) end(ctx workflow.Context, def StateDefinition, state map[string]interface{}, err error) {
+	if t == nil || t.finish == nil {
+		return
+	}
+
+	output, serr := serializeState(changedKeys(t.before, state))
+	if serr != nil {
+		workflow.GetLogger(ctx).Warn("Failed to serialize traced output", "name", def.Name, "error", serr)
+	}
+	t.finish(output, err)
+}
+
+// stateInput вычисляет вход состояния так же, как его вычислит само состояние.
+// Ошибку вычисления вернет само состояние, поэтому здесь она не сообщается.
+func stateInput(ctx workflow.Context, def StateDefinition, state map[string]interface{}) interface{} {
+	switch def.Type {
+	case "activity":
+		if input, err := parseInput(ctx, def.Input, state); err == nil {
+			return input
+		}
+	case "subworkflow", "userTask":
+		if input, err := parseObjectInput(ctx, def.Input, state); err == nil {
+			return input
+		}
+	}
+	return nil
+}
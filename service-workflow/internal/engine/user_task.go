@@ -0,0 +1,192 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// Activity, которыми состояние userTask сохраняет задачу; их регистрирует usertask.RegisterActivities
+const (
+	CreateUserTaskActivity = "CreateUserTaskActivity"
+	ExpireUserTaskActivity = "ExpireUserTaskActivity"
+)
+
+// Типы ошибок userTask
+const (
+	UserTaskTimeoutErrorType   = "UserTaskTimeout"   // Задачу не выполнили в срок и timeoutNext не задан
+	UserTaskCompletedErrorType = "UserTaskCompleted" // ExpireUserTaskActivity: задачу выполнили раньше, чем истек срок
+)
+
+// UserTaskRecord is a human task persisted by a userTask state
+type UserTaskRecord struct {
+	ID          string          `json:"id"`
+	WorkflowID  string          `json:"workflowId"`
+	RunID       string          `json:"runId"`
+	State       string          `json:"state"`
+	Signal      string          `json:"signal"` // Сигнал, которым API сообщает workflow о выполнении
+	Title       string          `json:"title"`
+	Description string          `json:"description,omitempty"`
+	Assignee    string          `json:"assignee,omitempty"`
+	Group       string          `json:"group,omitempty"`
+	TicketID    string          `json:"ticketId,omitempty"`
+	FormSchema  json.RawMessage `json:"formSchema,omitempty"`
+	DueAt       *time.Time      `json:"dueAt,omitempty"`
+}
+
+// UserTaskCompletion is the payload of the signal sent when a human task is completed
+type UserTaskCompletion struct {
+	TaskID      string      `json:"taskId"` // Пустой ID подходит к текущей задаче состояния, например при симуляции
+	CompletedBy string      `json:"completedBy,omitempty"`
+	Payload     interface{} `json:"payload"`
+}
+
+// UserTaskSignal returns the name of the signal completing tasks of a userTask state
+func UserTaskSignal(stateName string) string {
+	return "userTask." + stateName
+}
+
+// userTaskFields - поля задачи, которые берутся из input состояния; значения могут ссылаться на state
+var userTaskFields = []string{"title", "description", "assignee", "group", "ticketId"}
+
+// executeUserTask сохраняет задачу для человека и ждет ее выполнения через API или истечения срока.
+// Результат задачи записывается в output. Срок задается timeout или businessTimeout.
+// Ожидание не прерывается для continue-as-new: задача живет в рамках одного запуска.
+func (e *WorkflowEngine) executeUserTask(ctx workflow.Context, def StateDefinition, state map[string]interface{}) (string, error) {
+	logger := workflow.GetLogger(ctx)
+
+	input, err := parseObjectInput(ctx, def.Input, state)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse input: %w", err)
+	}
+	fields := make(map[string]string, len(userTaskFields))
+	for _, name := range userTaskFields {
+		fields[name] = stringify(input[name])
+	}
+
+	var id string
+	err = workflow.SideEffect(ctx, func(ctx workflow.Context) interface{} {
+		return uuid.NewString()
+	}).Get(&id)
+	if err != nil {
+		return "", err
+	}
+
+	info := workflow.GetInfo(ctx)
+	record := UserTaskRecord{
+		ID:          id,
+		WorkflowID:  info.WorkflowExecution.ID,
+		RunID:       info.WorkflowExecution.RunID,
+		State:       def.Name,
+		Signal:      UserTaskSignal(def.Name),
+		Title:       fields["title"],
+		Description: fields["description"],
+		Assignee:    fields["assignee"],
+		Group:       fields["group"],
+		TicketID:    fields["ticketId"],
+		FormSchema:  def.FormSchema,
+	}
+
+	var deadline time.Time
+	if def.Timeout != "" || def.BusinessTimeout != "" {
+		if deadline, err = e.waitDeadline(ctx, def); err != nil {
+			return "", err
+		}
+		record.DueAt = &deadline
+	}
+
+	activityCtx := workflow.WithActivityOptions(ctx, stateActivityOptions(ctx, def))
+	if err := workflow.ExecuteActivity(activityCtx, CreateUserTaskActivity, record).Get(ctx, nil); err != nil {
+		return "", fmt.Errorf("failed to create user task: %w", err)
+	}
+	logger.Info("User task created", "name", def.Name, "task", id, "assignee", record.Assignee, "group", record.Group)
+
+	var (
+		completion *UserTaskCompletion
+		timedOut   bool
+	)
+
+	signal := workflow.GetSignalChannel(ctx, record.Signal)
+	receive := func(c workflow.ReceiveChannel) {
+		var received UserTaskCompletion
+		c.Receive(ctx, &received)
+		if received.TaskID != "" && received.TaskID != id {
+			// Сигнал задачи, созданной этим состоянием раньше (например, на прошлой итерации цикла)
+			logger.Warn("Completion of another user task ignored", "name", def.Name, "task", received.TaskID)
+			return
+		}
+		completion = &received
+	}
+
+	selector := workflow.NewSelector(ctx)
+	selector.AddReceive(signal, func(c workflow.ReceiveChannel, more bool) {
+		receive(c)
+	})
+
+	if !deadline.IsZero() {
+		timerCtx, cancel := workflow.WithCancel(ctx)
+		defer cancel()
+
+		duration := deadline.Sub(workflow.Now(ctx))
+		if duration < 0 {
+			duration = 0
+		}
+		selector.AddFuture(workflow.NewTimer(timerCtx, duration), func(f workflow.Future) {
+			timedOut = true
+		})
+	}
+
+	cancellable := watchCancel(ctx, selector)
+	cancelled := func() bool { return cancellable && ctx.Err() != nil }
+
+	for completion == nil && !timedOut && !cancelled() {
+		selector.Select(ctx)
+	}
+
+	if completion == nil && cancelled() {
+		// Задача отмененной ветки больше не нужна, закрываем ее так же, как по истечении срока
+		expireCtx, _ := workflow.NewDisconnectedContext(activityCtx)
+		err := workflow.ExecuteActivity(expireCtx, ExpireUserTaskActivity, id).Get(expireCtx, nil)
+
+		var appErr *temporal.ApplicationError
+		if err != nil && !(errors.As(err, &appErr) && appErr.Type() == UserTaskCompletedErrorType) {
+			logger.Warn("Failed to expire user task of cancelled state", "name", def.Name, "task", id, "error", err)
+		}
+		return "", ctx.Err()
+	}
+
+	if completion == nil {
+		err := workflow.ExecuteActivity(activityCtx, ExpireUserTaskActivity, id).Get(ctx, nil)
+
+		var appErr *temporal.ApplicationError
+		switch {
+		case errors.As(err, &appErr) && appErr.Type() == UserTaskCompletedErrorType:
+			// Задачу выполнили одновременно с истечением срока, сигнал уже отправлен
+			for completion == nil {
+				receive(signal)
+			}
+
+		case err != nil:
+			return "", fmt.Errorf("failed to expire user task: %w", err)
+
+		default:
+			logger.Info("User task expired", "name", def.Name, "task", id)
+			if def.TimeoutNext == "" {
+				return "", temporal.NewNonRetryableApplicationError(
+					fmt.Sprintf("state %s: user task %s was not completed in time", def.Name, id), UserTaskTimeoutErrorType, nil)
+			}
+			return def.TimeoutNext, nil
+		}
+	}
+
+	logger.Info("User task completed", "name", def.Name, "task", id, "completedBy", completion.CompletedBy)
+	if def.Output != "" {
+		state[def.Output] = completion.Payload
+	}
+	return "", nil
+}
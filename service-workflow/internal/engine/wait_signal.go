@@ -0,0 +1,176 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// SignalTimeoutErrorType - тип ошибки, если waitForSignal не дождался сигнала и timeoutNext не задан
+const SignalTimeoutErrorType = "SignalTimeout"
+
+// SignalWait описывает сигнал, которого ждет состояние waitForSignal
+type SignalWait struct {
+	Name        string `json:"name"`
+	Next        string `json:"next,omitempty"`        // Переход после сигнала, по умолчанию - обычный переход состояния
+	Output      string `json:"output,omitempty"`      // Ключ state для payload, по умолчанию output состояния
+	PayloadType string `json:"payloadType,omitempty"` // Тип payload (Message, Assignment, Ticket), по умолчанию объект
+}
+
+// executeWaitForSignal блокируется до получения одного из сигналов или таймаута
+// и возвращает имя следующего состояния ("" - обычный переход).
+// interruptible - ожидание можно прервать для continue-as-new, оставшийся таймаут переносится.
+func (e *WorkflowEngine) executeWaitForSignal(ctx workflow.Context, r *run, def StateDefinition, state map[string]interface{}, interruptible bool) (string, error) {
+	logger := workflow.GetLogger(ctx)
+
+	var (
+		target      string
+		timedOut    bool
+		interrupted bool
+		err         error
+	)
+
+	// deliver сохраняет payload сигнала и запоминает переход
+	deliver := func(signal SignalWait, decode func(payload interface{}) error) {
+		payload, payloadErr := newPayload(signal.PayloadType)
+		if payloadErr != nil {
+			// Сигнал все равно вычитываем, чтобы не зациклиться на нем
+			_ = decode(nil)
+			err = payloadErr
+			return
+		}
+		if decodeErr := decode(payload.Interface()); decodeErr != nil {
+			err = decodeErr
+			return
+		}
+
+		output := signal.Output
+		if output == "" {
+			output = def.Output
+		}
+		if output != "" {
+			state[output] = payload.Elem().Interface()
+		}
+
+		logger.Info("Signal received", "name", def.Name, "signal", signal.Name)
+		target = signal.Next
+	}
+
+	// Сигналы, перенесенные из предыдущего запуска, обрабатываются без ожидания
+	for _, signal := range def.Signals {
+		if raw, ok := r.takeBuffered(signal.Name); ok {
+			deliver(signal, func(payload interface{}) error {
+				if payload == nil {
+					return nil
+				}
+				return json.Unmarshal(raw, payload)
+			})
+			return target, err
+		}
+	}
+
+	selector := workflow.NewSelector(ctx)
+	for _, signal := range def.Signals {
+		signal := signal
+		selector.AddReceive(workflow.GetSignalChannel(ctx, signal.Name), func(c workflow.ReceiveChannel, more bool) {
+			deliver(signal, func(payload interface{}) error {
+				c.Receive(ctx, payload)
+				return nil
+			})
+		})
+	}
+
+	// Срок из предыдущего запуска относится только к первому ожиданию верхнего уровня
+	var resumeDeadline *time.Time
+	if interruptible {
+		resumeDeadline, r.resumeDeadline = r.resumeDeadline, nil
+	}
+
+	var deadline time.Time
+	if def.Timeout != "" || def.BusinessTimeout != "" {
+		// После continue-as-new ждем только оставшееся время
+		if resumeDeadline != nil {
+			deadline = *resumeDeadline
+		} else {
+			var deadlineErr error
+			if deadline, deadlineErr = e.waitDeadline(ctx, def); deadlineErr != nil {
+				return "", deadlineErr
+			}
+		}
+
+		duration := deadline.Sub(workflow.Now(ctx))
+		if duration < 0 {
+			duration = 0
+		}
+
+		timerCtx, cancel := workflow.WithCancel(ctx)
+		defer cancel()
+
+		selector.AddFuture(workflow.NewTimer(timerCtx, duration), func(f workflow.Future) {
+			timedOut = true
+			target = def.TimeoutNext
+		})
+	}
+
+	if interruptible {
+		selector.AddReceive(r.checkpoint, func(c workflow.ReceiveChannel, more bool) {
+			c.Receive(ctx, nil)
+			interrupted = true
+		})
+	}
+
+	cancellable := watchCancel(ctx, selector)
+
+	logger.Info("Waiting for signal", "name", def.Name)
+	selector.Select(ctx)
+
+	// Таймер отмененной ветки тоже срабатывает, поэтому отмену проверяем первой
+	if cancellable && ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+
+	if interrupted {
+		if !deadline.IsZero() {
+			r.waitDeadline = &deadline
+		}
+		return "", errCheckpoint
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	if timedOut {
+		logger.Info("Signal wait timed out", "name", def.Name)
+		if target == "" {
+			timeout := def.Timeout
+			if def.BusinessTimeout != "" {
+				timeout = def.BusinessTimeout + " of " + def.Calendar + " working time"
+			}
+			return "", temporal.NewNonRetryableApplicationError(
+				fmt.Sprintf("state %s: no signal received within %s", def.Name, timeout), SignalTimeoutErrorType, nil)
+		}
+	}
+
+	return target, nil
+}
+
+// waitDeadline возвращает срок ожидания: timeout отсчитывается по часам, businessTimeout - по рабочему календарю
+func (e *WorkflowEngine) waitDeadline(ctx workflow.Context, def StateDefinition) (time.Time, error) {
+	if def.BusinessTimeout != "" {
+		deadline, err := e.businessDeadline(ctx, def.Calendar, def.BusinessTimeout)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("state %s: %w", def.Name, err)
+		}
+		return deadline, nil
+	}
+
+	duration, err := time.ParseDuration(def.Timeout)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timeout for state %s: %w", def.Name, err)
+	}
+	return workflow.Now(ctx).Add(duration), nil
+}
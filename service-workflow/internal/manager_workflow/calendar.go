@@ -0,0 +1,70 @@
+package manager_workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aimustaev/service-workflow/internal/engine"
+)
+
+// CalendarConfig represents a business-hours calendar stored in the database
+type CalendarConfig struct {
+	Name      string          `json:"name" db:"name"`
+	Content   json.RawMessage `json:"content" db:"content"` // engine.Calendar
+	CreatedAt time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at" db:"updated_at"`
+	CreatedBy string          `json:"created_by" db:"created_by"`
+}
+
+// CalendarRepository defines the interface for working with calendars
+type CalendarRepository interface {
+	// Get returns a calendar by name or nil if it does not exist
+	Get(name string) (*CalendarConfig, error)
+
+	// List returns all calendars sorted by name
+	List() ([]*CalendarConfig, error)
+
+	// Save creates a calendar or replaces the content of an existing one
+	Save(calendar *CalendarConfig) error
+
+	// Delete deletes a calendar by name
+	Delete(name string) error
+}
+
+// CalendarManager отдает календари движку и валидатору.
+// Календари не кэшируются: движок загружает календарь один раз при запуске таймера и сохраняет его в истории.
+type CalendarManager struct {
+	repo CalendarRepository
+}
+
+// NewCalendarManager creates a new calendar manager
+func NewCalendarManager(repo CalendarRepository) *CalendarManager {
+	return &CalendarManager{repo: repo}
+}
+
+// GetCalendar returns a parsed calendar by name
+func (m *CalendarManager) GetCalendar(name string) (engine.Calendar, error) {
+	stored, err := m.repo.Get(name)
+	if err != nil {
+		return engine.Calendar{}, err
+	}
+	if stored == nil {
+		return engine.Calendar{}, ErrCalendarNotFound
+	}
+	return ParseCalendar(stored)
+}
+
+// ParseCalendar decodes stored calendar content, the name is always taken from the record
+func ParseCalendar(stored *CalendarConfig) (engine.Calendar, error) {
+	var calendar engine.Calendar
+	if err := json.Unmarshal(stored.Content, &calendar); err != nil {
+		return engine.Calendar{}, fmt.Errorf("failed to unmarshal calendar %s: %w", stored.Name, err)
+	}
+	calendar.Name = stored.Name
+	return calendar, nil
+}
+
+var (
+	ErrCalendarNotFound = engine.ErrCalendarNotFound
+)
@@ -0,0 +1,92 @@
+package manager_workflow
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// PostgresCalendarRepository implements CalendarRepository using PostgreSQL
+type PostgresCalendarRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresCalendarRepository creates a new instance of PostgresCalendarRepository
+func NewPostgresCalendarRepository(db *sqlx.DB) *PostgresCalendarRepository {
+	return &PostgresCalendarRepository{db: db}
+}
+
+// Get returns a calendar by name or nil if it does not exist
+func (r *PostgresCalendarRepository) Get(name string) (*CalendarConfig, error) {
+	query := `
+		SELECT name, content, created_at, updated_at, created_by
+		FROM configs.calendars
+		WHERE name = $1
+	`
+
+	var calendar CalendarConfig
+	err := r.db.Get(&calendar, query, name)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get calendar: %w", err)
+	}
+
+	return &calendar, nil
+}
+
+// List returns all calendars sorted by name
+func (r *PostgresCalendarRepository) List() ([]*CalendarConfig, error) {
+	query := `
+		SELECT name, content, created_at, updated_at, created_by
+		FROM configs.calendars
+		ORDER BY name
+	`
+
+	var calendars []*CalendarConfig
+	if err := r.db.Select(&calendars, query); err != nil {
+		return nil, fmt.Errorf("failed to list calendars: %w", err)
+	}
+
+	return calendars, nil
+}
+
+// Save creates a calendar or replaces the content of an existing one
+func (r *PostgresCalendarRepository) Save(calendar *CalendarConfig) error {
+	query := `
+		INSERT INTO configs.calendars (name, content, created_at, updated_at, created_by)
+		VALUES ($1, $2, $3, $3, $4)
+		ON CONFLICT (name) DO UPDATE
+		SET content = EXCLUDED.content, updated_at = EXCLUDED.updated_at
+		RETURNING created_at, updated_at, created_by
+	`
+
+	err := r.db.QueryRowx(query, calendar.Name, calendar.Content, time.Now(), calendar.CreatedBy).
+		Scan(&calendar.CreatedAt, &calendar.UpdatedAt, &calendar.CreatedBy)
+	if err != nil {
+		return fmt.Errorf("failed to save calendar: %w", err)
+	}
+
+	return nil
+}
+
+// Delete deletes a calendar by name
+func (r *PostgresCalendarRepository) Delete(name string) error {
+	result, err := r.db.Exec(`DELETE FROM configs.calendars WHERE name = $1`, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete calendar: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrCalendarNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package manager_workflow
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ConfigChangesChannel is the Postgres NOTIFY channel the config_versions trigger writes changed config names to
+const ConfigChangesChannel = "config_versions_changed"
+
+// Интервалы переподключения и проверки соединения подписки
+const (
+	listenMinReconnect = time.Second
+	listenMaxReconnect = time.Minute
+	listenPingInterval = 30 * time.Second
+)
+
+// Listen subscribes the manager to config change notifications of Postgres.
+// A notified config is reloaded into the cache right away and polling by updateInterval is paused.
+// While the connection is lost the manager falls back to polling; after reconnecting it reloads
+// all cached configs, because notifications sent in the meantime are lost.
+func (m *ConfigManager) Listen(ctx context.Context, dsn string) {
+	listener := pq.NewListener(dsn, listenMinReconnect, listenMaxReconnect, func(event pq.ListenerEventType, err error) {
+		switch event {
+		case pq.ListenerEventDisconnected:
+			log.Printf("Config notifications disconnected, falling back to polling: %v", err)
+			m.listening.Store(false)
+		case pq.ListenerEventConnectionAttemptFailed:
+			log.Printf("Failed to connect for config notifications: %v", err)
+		}
+	})
+
+	go m.listenLoop(ctx, listener)
+}
+
+// listenLoop обрабатывает уведомления, пока не остановлен менеджер или не отменен контекст
+func (m *ConfigManager) listenLoop(ctx context.Context, listener *pq.Listener) {
+	// Закрытие прерывает и ожидание соединения в listener.Listen
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-m.stopChan:
+		}
+		m.listening.Store(false)
+		listener.Close()
+	}()
+
+	// listener.Listen ждет первого соединения, поэтому подписываемся здесь, а не в Listen
+	if err := listener.Listen(ConfigChangesChannel); err != nil {
+		log.Printf("Failed to listen for config notifications, using polling only: %v", err)
+		return
+	}
+	log.Printf("Listening for config notifications on %s", ConfigChangesChannel)
+	m.reloadCached()
+	m.listening.Store(true)
+
+	ping := time.NewTicker(listenPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case notification, ok := <-listener.NotificationChannel():
+			if !ok {
+				// Подписка закрыта вместе с менеджером
+				return
+			}
+			// nil приходит после переподключения: уведомления за время разрыва потеряны
+			if notification == nil {
+				log.Printf("Config notifications reconnected, reloading cached configs")
+				m.reloadCached()
+				m.listening.Store(true)
+				continue
+			}
+			m.stats.notifications.Add(1)
+			m.invalidate(notification.Extra)
+
+		case <-ping.C:
+			// Ping обнаруживает разрыв соединения, о котором сервер не успел сообщить
+			if err := listener.Ping(); err != nil {
+				log.Printf("Config notifications ping failed: %v", err)
+			}
+		}
+	}
+}
+
+// invalidate перечитывает конфигурацию, если она закэширована; остальные загрузятся при первом обращении
+func (m *ConfigManager) invalidate(name string) {
+	m.cacheMutex.RLock()
+	_, cached := m.cache[name]
+	m.cacheMutex.RUnlock()
+	if !cached {
+		return
+	}
+
+	if err := m.Refresh(name); err != nil {
+		// Удаляем из кэша, чтобы следующее обращение загрузило конфигурацию заново
+		log.Printf("Failed to reload config %s after notification: %v", name, err)
+		m.cacheMutex.Lock()
+		delete(m.cache, name)
+		m.cacheMutex.Unlock()
+	}
+}
+
+// reloadCached перечитывает все закэшированные конфигурации
+func (m *ConfigManager) reloadCached() {
+	for _, name := range m.cachedNames() {
+		m.invalidate(name)
+	}
+}
@@ -7,8 +7,12 @@ import (
 	"fmt"
 	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/google/uuid"
+	"go.temporal.io/sdk/client"
+
 	"github.com/aimustaev/service-workflow/internal/engine"
 )
 
@@ -16,9 +20,30 @@ import (
 type ConfigManager struct {
 	repo           ConfigVersionRepository
 	cache          map[string]*cachedConfig
+	versions       map[string]engine.WorkflowDefinition // id@version -> определение; версии неизменяемы, поэтому не устаревает
 	cacheMutex     sync.RWMutex
 	updateInterval time.Duration
 	stopChan       chan struct{}
+	schedules      client.ScheduleClient // Temporal Schedules для определений со schedule, может быть nil
+	listening      atomic.Bool           // Изменения приходят через LISTEN/NOTIFY, опрос по таймеру не нужен
+	refreshing     sync.Map              // Имена, которые сейчас обновляются в фоне
+	stats          cacheCounters
+}
+
+// CacheStats contains counters of the configuration cache since the manager was created
+type CacheStats struct {
+	Hits          uint64 `json:"hits"`          // Определение отдано из кэша
+	Misses        uint64 `json:"misses"`        // Определения не было в кэше, оно загружено из БД
+	Reloads       uint64 `json:"reloads"`       // Закэшированная конфигурация перечитана из БД: по NOTIFY, опросу или устареванию
+	Notifications uint64 `json:"notifications"` // Получено уведомлений об изменении конфигураций
+	Listening     bool   `json:"listening"`     // Подписка на уведомления активна, опрос отключен
+}
+
+type cacheCounters struct {
+	hits          atomic.Uint64
+	misses        atomic.Uint64
+	reloads       atomic.Uint64
+	notifications atomic.Uint64
 }
 
 type cachedConfig struct {
@@ -33,6 +58,7 @@ func NewConfigManager(repo ConfigVersionRepository, updateInterval time.Duration
 	return &ConfigManager{
 		repo:           repo,
 		cache:          make(map[string]*cachedConfig),
+		versions:       make(map[string]engine.WorkflowDefinition),
 		updateInterval: updateInterval,
 		stopChan:       make(chan struct{}),
 	}
@@ -58,18 +84,15 @@ func (m *ConfigManager) GetWorkflowDefinition(name string) (engine.WorkflowDefin
 
 	if !exists {
 		log.Printf("Cache miss for workflow: %s, loading from database", name)
+		m.stats.misses.Add(1)
 		return m.loadAndCacheConfig(name)
 	}
+	m.stats.hits.Add(1)
 
-	// Проверяем, не устарела ли конфигурация
-	if time.Since(cached.updatedAt) > m.updateInterval {
+	// Пока работает подписка на уведомления, кэш актуален независимо от возраста
+	if !m.listening.Load() && time.Since(cached.updatedAt) > m.updateInterval {
 		log.Printf("Config for %s is stale (age: %v), updating in background", name, time.Since(cached.updatedAt))
-		// Пытаемся обновить конфигурацию в фоне
-		go func() {
-			if err := m.updateConfig(name); err != nil {
-				log.Printf("Failed to update config for %s: %v", name, err)
-			}
-		}()
+		m.updateInBackground(name)
 	} else {
 		log.Printf("Using cached config for %s (age: %v)", name, time.Since(cached.updatedAt))
 	}
@@ -77,6 +100,87 @@ func (m *ConfigManager) GetWorkflowDefinition(name string) (engine.WorkflowDefin
 	return cached.definition, nil
 }
 
+// Stats returns the counters of the configuration cache
+func (m *ConfigManager) Stats() CacheStats {
+	return CacheStats{
+		Hits:          m.stats.hits.Load(),
+		Misses:        m.stats.misses.Load(),
+		Reloads:       m.stats.reloads.Load(),
+		Notifications: m.stats.notifications.Load(),
+		Listening:     m.listening.Load(),
+	}
+}
+
+// updateInBackground обновляет конфигурацию в фоне; для одного имени одновременно работает одно обновление
+func (m *ConfigManager) updateInBackground(name string) {
+	if _, running := m.refreshing.LoadOrStore(name, struct{}{}); running {
+		return
+	}
+
+	go func() {
+		defer m.refreshing.Delete(name)
+		if err := m.updateConfig(name); err != nil {
+			log.Printf("Failed to update config for %s: %v", name, err)
+		}
+	}()
+}
+
+// GetActiveConfigRef returns the ID and version of the currently active configuration for the given name
+func (m *ConfigManager) GetActiveConfigRef(name string) (ConfigRef, error) {
+	m.cacheMutex.RLock()
+	cached, exists := m.cache[name]
+	m.cacheMutex.RUnlock()
+
+	if !exists {
+		m.stats.misses.Add(1)
+		if _, err := m.loadAndCacheConfig(name); err != nil {
+			return ConfigRef{}, err
+		}
+		m.cacheMutex.RLock()
+		cached = m.cache[name]
+		m.cacheMutex.RUnlock()
+	} else {
+		m.stats.hits.Add(1)
+	}
+
+	return ConfigRef{ID: cached.config.ID, Version: cached.config.Version}, nil
+}
+
+// GetWorkflowDefinitionVersion returns the workflow definition for an exact configuration version.
+// Versions are loaded regardless of is_active, so executions pinned to a deactivated version keep working.
+func (m *ConfigManager) GetWorkflowDefinitionVersion(id uuid.UUID, version string) (engine.WorkflowDefinition, error) {
+	key := versionKey(id, version)
+
+	m.cacheMutex.RLock()
+	def, exists := m.versions[key]
+	m.cacheMutex.RUnlock()
+	if exists {
+		m.stats.hits.Add(1)
+		return def, nil
+	}
+
+	log.Printf("Cache miss for workflow version: %s, loading from database", key)
+	m.stats.misses.Add(1)
+	config, err := m.repo.GetByVersion(id, version)
+	if err != nil {
+		return engine.WorkflowDefinition{}, err
+	}
+	if config == nil {
+		return engine.WorkflowDefinition{}, ErrConfigNotFound
+	}
+
+	def, err = m.parseConfig(config)
+	if err != nil {
+		return engine.WorkflowDefinition{}, err
+	}
+
+	m.cacheMutex.Lock()
+	m.versions[key] = def
+	m.cacheMutex.Unlock()
+
+	return def, nil
+}
+
 // GetWorkflowSchema возвращает визуальную схему для воркфлоу
 func (m *ConfigManager) GetWorkflowSchema(name string) (*json.RawMessage, error) {
 	m.cacheMutex.RLock()
@@ -84,6 +188,7 @@ func (m *ConfigManager) GetWorkflowSchema(name string) (*json.RawMessage, error)
 	m.cacheMutex.RUnlock()
 
 	if !exists {
+		m.stats.misses.Add(1)
 		config, err := m.repo.GetLatestActive(name)
 		if err != nil {
 			return nil, err
@@ -109,9 +214,44 @@ func (m *ConfigManager) GetWorkflowSchema(name string) (*json.RawMessage, error)
 		return config.Schema, nil
 	}
 
+	m.stats.hits.Add(1)
 	return cached.schema, nil
 }
 
+// Refresh reloads the active version of a config into the cache right away instead of waiting for updateInterval.
+// If the config has no active version anymore, it is removed from the cache.
+func (m *ConfigManager) Refresh(name string) error {
+	m.stats.reloads.Add(1)
+	config, err := m.repo.GetLatestActive(name)
+	if err != nil {
+		return err
+	}
+	if config == nil {
+		m.cacheMutex.Lock()
+		delete(m.cache, name)
+		m.cacheMutex.Unlock()
+		log.Printf("Removed workflow configuration %s from cache: no active version", name)
+		return nil
+	}
+
+	def, err := m.parseConfig(config)
+	if err != nil {
+		return err
+	}
+
+	m.cacheMutex.Lock()
+	m.cache[name] = &cachedConfig{
+		config:     config,
+		definition: def,
+		schema:     config.Schema,
+		updatedAt:  time.Now(),
+	}
+	m.cacheMutex.Unlock()
+
+	log.Printf("Refreshed workflow configuration for %s to version %s", name, config.Version)
+	return nil
+}
+
 // loadAndCacheConfig загружает конфигурацию из БД и кэширует её
 func (m *ConfigManager) loadAndCacheConfig(name string) (engine.WorkflowDefinition, error) {
 	config, err := m.repo.GetLatestActive(name)
@@ -140,6 +280,7 @@ func (m *ConfigManager) loadAndCacheConfig(name string) (engine.WorkflowDefiniti
 
 // updateConfig обновляет конфигурацию в кэше
 func (m *ConfigManager) updateConfig(name string) error {
+	m.stats.reloads.Add(1)
 	config, err := m.repo.GetLatestActive(name)
 	if err != nil {
 		return err
@@ -189,14 +330,17 @@ func (m *ConfigManager) updateLoop(ctx context.Context) {
 			log.Printf("ConfigManager update loop stopped: stop signal received")
 			return
 		case <-ticker.C:
-			log.Printf("Running periodic config update check")
-			m.cacheMutex.RLock()
-			names := make([]string, 0, len(m.cache))
-			for name := range m.cache {
-				names = append(names, name)
+			stats := m.Stats()
+			log.Printf("Config cache: hits=%d misses=%d reloads=%d notifications=%d listening=%v",
+				stats.Hits, stats.Misses, stats.Reloads, stats.Notifications, stats.Listening)
+
+			// Опрос - запасной путь на время, пока подписка на уведомления не работает
+			if stats.Listening {
+				continue
 			}
-			m.cacheMutex.RUnlock()
 
+			log.Printf("Running periodic config update check")
+			names := m.cachedNames()
 			log.Printf("Checking updates for workflows: %v", names)
 			for _, name := range names {
 				if err := m.updateConfig(name); err != nil {
@@ -207,6 +351,23 @@ func (m *ConfigManager) updateLoop(ctx context.Context) {
 	}
 }
 
+// cachedNames возвращает имена закэшированных конфигураций
+func (m *ConfigManager) cachedNames() []string {
+	m.cacheMutex.RLock()
+	defer m.cacheMutex.RUnlock()
+
+	names := make([]string, 0, len(m.cache))
+	for name := range m.cache {
+		names = append(names, name)
+	}
+	return names
+}
+
+// versionKey формирует ключ кэша конкретной версии
+func versionKey(id uuid.UUID, version string) string {
+	return id.String() + "@" + version
+}
+
 // parseConfig парсит конфигурацию в определение воркфлоу
 func (m *ConfigManager) parseConfig(config *ConfigVersion) (engine.WorkflowDefinition, error) {
 	var def engine.WorkflowDefinition
@@ -217,5 +378,9 @@ func (m *ConfigManager) parseConfig(config *ConfigVersion) (engine.WorkflowDefin
 }
 
 var (
-	ErrConfigNotFound = errors.New("configuration not found")
+	ErrConfigNotFound   = errors.New("configuration not found")
+	ErrVersionImmutable = errors.New("content of a stored configuration version cannot be changed, create a new version")
+
+	ErrActiveVersionRequired = errors.New("the active version cannot be deactivated, activate or roll back to another version or deactivate the configuration instead")
+	ErrActiveVersionChanged  = errors.New("the active version was changed concurrently")
 )
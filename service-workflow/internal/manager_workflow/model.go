@@ -20,6 +20,12 @@ type ConfigVersion struct {
 	IsActive  bool             `json:"is_active" db:"is_active"`
 }
 
+// ConfigRef identifies the exact configuration version a workflow execution is pinned to
+type ConfigRef struct {
+	ID      uuid.UUID `json:"id"`
+	Version string    `json:"version"`
+}
+
 // ConfigVersionFilter represents filters for querying config versions
 type ConfigVersionFilter struct {
 	ID       *uuid.UUID
@@ -37,7 +43,9 @@ type ConfigVersionSummary struct {
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 }
 
-// ConfigVersionRepository defines the interface for working with config versions
+// ConfigVersionRepository defines the interface for working with config versions.
+// A configuration has at most one active version. The active version can only be replaced by another one
+// through Activate, Rollback or an active Create/Update, or cleared for the whole configuration through Deactivate.
 type ConfigVersionRepository interface {
 	// GetLatestActive returns the latest active version of a configuration by name
 	GetLatestActive(name string) (*ConfigVersion, error)
@@ -48,14 +56,27 @@ type ConfigVersionRepository interface {
 	// Create creates a new version of a configuration
 	Create(config *ConfigVersion) error
 
-	// Update updates an existing configuration version
+	// Update updates the schema and activity of an existing configuration version.
+	// The content of a stored version cannot be changed, it returns ErrVersionImmutable.
 	Update(config *ConfigVersion) error
 
 	// List returns a list of configuration versions matching the filter
 	List(filter ConfigVersionFilter) ([]*ConfigVersion, error)
 
-	// Deactivate deactivates a specific version of a configuration
-	Deactivate(id uuid.UUID, version string) error
+	// Deactivate switches the configuration off: its active version is deactivated, so it has none until the next activation.
+	// It returns the version that was active or ErrConfigNotFound if the configuration has no active version.
+	Deactivate(name string) (*ConfigVersion, error)
+
+	// Activate makes the version the only active version of the configuration and records it in the activation history.
+	// It returns ErrConfigNotFound if the version does not exist.
+	Activate(name, version string) (*ConfigVersion, error)
+
+	// GetPreviouslyActive returns the version that was active before the current active version, or nil if there is none
+	GetPreviouslyActive(name string) (*ConfigVersion, error)
+
+	// Rollback makes the previously active version active again and removes the current one from the activation history.
+	// It returns ErrActiveVersionChanged if version is no longer the previously active version.
+	Rollback(name, version string) (*ConfigVersion, error)
 
 	// ListNames returns a list of all unique configuration names
 	ListNames() ([]string, error)
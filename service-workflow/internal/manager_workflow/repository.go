@@ -79,7 +79,23 @@ func (r *PostgresConfigRepository) Create(config *ConfigVersion) error {
 	config.CreatedAt = now
 	config.UpdatedAt = now
 
-	_, err := r.db.Exec(query,
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Активной может быть только одна версия конфигурации
+	if config.IsActive {
+		if err := deactivateOthers(tx, config.Name, config.Version, now); err != nil {
+			return err
+		}
+		if err := pushActivation(tx, config.Name, config.Version, now); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(query,
 		config.ID,
 		config.Name,
 		config.Version,
@@ -94,21 +110,67 @@ func (r *PostgresConfigRepository) Create(config *ConfigVersion) error {
 		return fmt.Errorf("failed to create config version: %w", err)
 	}
 
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit config version: %w", err)
+	}
+
 	return nil
 }
 
-// Update updates an existing configuration version
+// Update updates the schema and activity of an existing configuration version.
+// The content of a stored version is immutable, a different content returns ErrVersionImmutable.
 func (r *PostgresConfigRepository) Update(config *ConfigVersion) error {
 	query := `
 		UPDATE configs.config_versions
-		SET content = $1, schema = $2, updated_at = $3, is_active = $4
-		WHERE id = $5 AND version = $6
+		SET schema = $1, updated_at = $2, is_active = $3
+		WHERE id = $4 AND version = $5
 	`
 
 	config.UpdatedAt = time.Now()
 
-	result, err := r.db.Exec(query,
-		config.Content,
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Выполнения закреплены за id@version, поэтому содержимое версии менять нельзя:
+	// воркеры с закэшированной и с перечитанной версией воспроизводили бы разные состояния
+	var current struct {
+		Name     string `db:"name"`
+		Same     bool   `db:"same"`
+		IsActive bool   `db:"is_active"`
+	}
+	err = tx.Get(&current, `
+		SELECT name, content = $3::jsonb AS same, is_active
+		FROM configs.config_versions
+		WHERE id = $1 AND version = $2
+		FOR UPDATE
+	`, config.ID, config.Version, string(config.Content))
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("config version not found: %s@%s", config.ID, config.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get config version: %w", err)
+	}
+	if !current.Same {
+		return ErrVersionImmutable
+	}
+
+	// Активную версию можно только сменить другой, иначе у конфигурации не останется активной версии
+	if current.IsActive && !config.IsActive {
+		return ErrActiveVersionRequired
+	}
+	if config.IsActive && !current.IsActive {
+		if err := deactivateOthers(tx, current.Name, config.Version, config.UpdatedAt); err != nil {
+			return err
+		}
+		if err := pushActivation(tx, current.Name, config.Version, config.UpdatedAt); err != nil {
+			return err
+		}
+	}
+
+	result, err := tx.Exec(query,
 		config.Schema,
 		config.UpdatedAt,
 		config.IsActive,
@@ -127,6 +189,10 @@ func (r *PostgresConfigRepository) Update(config *ConfigVersion) error {
 		return fmt.Errorf("config version not found: %s@%s", config.ID, config.Version)
 	}
 
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit config version: %w", err)
+	}
+
 	return nil
 }
 
@@ -180,27 +246,227 @@ func (r *PostgresConfigRepository) List(filter ConfigVersionFilter) ([]*ConfigVe
 	return configs, nil
 }
 
-// Deactivate deactivates a specific version of a configuration
-func (r *PostgresConfigRepository) Deactivate(id uuid.UUID, version string) error {
-	query := `
+// Deactivate switches the configuration off in one transaction: its active version is deactivated.
+// The activation history is kept, the next Activate continues it.
+func (r *PostgresConfigRepository) Deactivate(name string) (*ConfigVersion, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockVersions(tx, name); err != nil {
+		return nil, err
+	}
+
+	var config ConfigVersion
+	err = tx.Get(&config, `
 		UPDATE configs.config_versions
 		SET is_active = false, updated_at = $1
-		WHERE id = $2 AND version = $3
-	`
+		WHERE name = $2 AND is_active = true
+		RETURNING id, name, version, content, schema, created_at, updated_at, created_by, is_active
+	`, time.Now(), name)
+	if err == sql.ErrNoRows {
+		return nil, ErrConfigNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to deactivate config: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit deactivation: %w", err)
+	}
 
-	result, err := r.db.Exec(query, time.Now(), id, version)
+	return &config, nil
+}
+
+// Activate makes the version the only active version of the configuration in one transaction
+func (r *PostgresConfigRepository) Activate(name, version string) (*ConfigVersion, error) {
+	tx, err := r.db.Beginx()
 	if err != nil {
-		return fmt.Errorf("failed to deactivate config version: %w", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	rows, err := result.RowsAffected()
+	if err := lockVersions(tx, name); err != nil {
+		return nil, err
+	}
+
+	var config ConfigVersion
+	err = tx.Get(&config, `
+		SELECT id, name, version, content, schema, created_at, updated_at, created_by, is_active
+		FROM configs.config_versions
+		WHERE name = $1 AND version = $2
+	`, name, version)
+	if err == sql.ErrNoRows {
+		return nil, ErrConfigNotFound
+	}
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return nil, fmt.Errorf("failed to get config version: %w", err)
 	}
-	if rows == 0 {
-		return fmt.Errorf("config version not found: %s@%s", id, version)
+
+	now := time.Now()
+
+	// Сначала снимаем активность с остальных версий: уникальный индекс проверяется построчно
+	if err := deactivateOthers(tx, name, version, now); err != nil {
+		return nil, err
+	}
+	if !config.IsActive {
+		_, err := tx.Exec(`
+			UPDATE configs.config_versions
+			SET is_active = true, updated_at = $1
+			WHERE name = $2 AND version = $3
+		`, now, name, version)
+		if err != nil {
+			return nil, fmt.Errorf("failed to activate config version: %w", err)
+		}
+		if err := pushActivation(tx, name, version, now); err != nil {
+			return nil, err
+		}
+		config.IsActive = true
+		config.UpdatedAt = now
 	}
 
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit activation: %w", err)
+	}
+
+	return &config, nil
+}
+
+// GetPreviouslyActive returns the version that was active before the current active version, or nil if there is none
+func (r *PostgresConfigRepository) GetPreviouslyActive(name string) (*ConfigVersion, error) {
+	previous, err := previouslyActive(r.db, name)
+	if err != nil {
+		return nil, err
+	}
+	if previous == "" {
+		return nil, nil
+	}
+
+	configs, err := r.List(ConfigVersionFilter{Name: &name, Version: &previous})
+	if err != nil {
+		return nil, err
+	}
+	if len(configs) == 0 {
+		return nil, nil
+	}
+	return configs[0], nil
+}
+
+// Rollback makes the previously active version active again and removes the current one from the activation history,
+// so the next rollback goes one activation further back. version is the expected previously active version;
+// if another activation happened in the meantime, it returns ErrActiveVersionChanged.
+func (r *PostgresConfigRepository) Rollback(name, version string) (*ConfigVersion, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockVersions(tx, name); err != nil {
+		return nil, err
+	}
+
+	previous, err := previouslyActive(tx, name)
+	if err != nil {
+		return nil, err
+	}
+	if previous != version {
+		return nil, ErrActiveVersionChanged
+	}
+
+	// Снимаем с вершины истории записи текущей версии, вершиной становится активируемая
+	_, err = tx.Exec(`
+		DELETE FROM configs.config_activations
+		WHERE name = $1 AND id > (
+			SELECT max(id) FROM configs.config_activations WHERE name = $1 AND version = $2
+		)
+	`, name, version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update activation history: %w", err)
+	}
+
+	now := time.Now()
+	if err := deactivateOthers(tx, name, version, now); err != nil {
+		return nil, err
+	}
+
+	var config ConfigVersion
+	err = tx.Get(&config, `
+		UPDATE configs.config_versions
+		SET is_active = true, updated_at = $1
+		WHERE name = $2 AND version = $3
+		RETURNING id, name, version, content, schema, created_at, updated_at, created_by, is_active
+	`, now, name, version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to activate config version: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit rollback: %w", err)
+	}
+
+	return &config, nil
+}
+
+// lockVersions блокирует все версии конфигурации, чтобы параллельные активации выполнялись по очереди
+func lockVersions(tx *sqlx.Tx, name string) error {
+	if _, err := tx.Exec(`SELECT 1 FROM configs.config_versions WHERE name = $1 FOR UPDATE`, name); err != nil {
+		return fmt.Errorf("failed to lock config versions: %w", err)
+	}
+	return nil
+}
+
+// pushActivation записывает активацию версии в историю, по которой работает Rollback
+func pushActivation(tx *sqlx.Tx, name, version string, now time.Time) error {
+	_, err := tx.Exec(`
+		INSERT INTO configs.config_activations (name, version, activated_at)
+		VALUES ($1, $2, $3)
+	`, name, version, now)
+	if err != nil {
+		return fmt.Errorf("failed to record activation: %w", err)
+	}
+	return nil
+}
+
+// previouslyActive возвращает версию, активную до текущей: последнюю запись истории,
+// которая не относится к текущей активной версии. Пустая строка - такой версии нет.
+func previouslyActive(q sqlx.Queryer, name string) (string, error) {
+	var version string
+	err := sqlx.Get(q, &version, `
+		SELECT a.version
+		FROM configs.config_activations a
+		WHERE a.name = $1 AND a.id < (
+			SELECT max(c.id)
+			FROM configs.config_activations c
+			JOIN configs.config_versions v ON v.name = c.name AND v.version = c.version AND v.is_active = true
+			WHERE c.name = $1
+		) AND a.version NOT IN (
+			SELECT version FROM configs.config_versions WHERE name = $1 AND is_active = true
+		)
+		ORDER BY a.id DESC
+		LIMIT 1
+	`, name)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get previously active config version: %w", err)
+	}
+	return version, nil
+}
+
+// deactivateOthers снимает активность со всех версий конфигурации, кроме указанной
+func deactivateOthers(tx *sqlx.Tx, name, version string, now time.Time) error {
+	_, err := tx.Exec(`
+		UPDATE configs.config_versions
+		SET is_active = false, updated_at = $1
+		WHERE name = $2 AND version <> $3 AND is_active = true
+	`, now, name, version)
+	if err != nil {
+		return fmt.Errorf("failed to deactivate other config versions: %w", err)
+	}
 	return nil
 }
 
@@ -0,0 +1,275 @@
+package manager_workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+
+	"github.com/aimustaev/service-workflow/internal/engine"
+)
+
+// schedulePrefix отделяет расписания конфигураций от остальных расписаний namespace
+const schedulePrefix = "config-"
+
+// scheduleTaskQueue - очередь воркера, который выполняет DynamicTicketWorkflow
+const scheduleTaskQueue = "workflow-ticket"
+
+// ScheduleSummary describes the Temporal Schedule of a config
+type ScheduleSummary struct {
+	ConfigName      string      `json:"config_name"`
+	ScheduleID      string      `json:"schedule_id"`
+	Cron            []string    `json:"cron"`
+	TimeZone        string      `json:"time_zone,omitempty"`
+	Paused          bool        `json:"paused"`
+	Note            string      `json:"note,omitempty"`
+	NextActionTimes []time.Time `json:"next_action_times"`
+	RecentActions   []time.Time `json:"recent_actions"` // Фактическое время последних запусков
+}
+
+// ScheduleID returns the Temporal Schedule ID of a config
+func ScheduleID(name string) string {
+	return schedulePrefix + name
+}
+
+// SetScheduleClient enables reconciliation of definition schedules into Temporal Schedules.
+// It must be called before the manager is used; without it ReconcileSchedule does nothing
+// and schedule operations return ErrSchedulesDisabled.
+func (m *ConfigManager) SetScheduleClient(schedules client.ScheduleClient) {
+	m.schedules = schedules
+}
+
+// ReconcileSchedule приводит расписание конфигурации к активной версии: создает или обновляет его,
+// если в определении есть schedule, и удаляет, если schedule нет или активной версии не осталось.
+// Пауза, выставленная через API, при обновлении сохраняется.
+func (m *ConfigManager) ReconcileSchedule(ctx context.Context, name string) error {
+	if m.schedules == nil {
+		return nil
+	}
+
+	var schedule *engine.Schedule
+	config, err := m.repo.GetLatestActive(name)
+	if err != nil {
+		return err
+	}
+	if config != nil {
+		def, err := m.parseConfig(config)
+		if err != nil {
+			return err
+		}
+		schedule = def.Schedule
+	}
+
+	handle := m.schedules.GetHandle(ctx, ScheduleID(name))
+
+	if schedule == nil {
+		err := handle.Delete(ctx)
+		if isNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to delete schedule of %s: %w", name, err)
+		}
+		log.Printf("Deleted schedule of %s", name)
+		return nil
+	}
+
+	spec, action, overlap, err := scheduleOptions(name, *schedule)
+	if err != nil {
+		return fmt.Errorf("invalid schedule of %s: %w", name, err)
+	}
+
+	err = handle.Update(ctx, client.ScheduleUpdateOptions{
+		DoUpdate: func(input client.ScheduleUpdateInput) (*client.ScheduleUpdate, error) {
+			updated := input.Description.Schedule
+			updated.Spec = spec
+			updated.Action = action
+
+			// Остальные политики и состояние (пауза) остаются как есть
+			policy := client.SchedulePolicies{}
+			if updated.Policy != nil {
+				policy = *updated.Policy
+			}
+			policy.Overlap = overlap
+			updated.Policy = &policy
+
+			return &client.ScheduleUpdate{Schedule: &updated}, nil
+		},
+	})
+	if err == nil {
+		log.Printf("Updated schedule of %s: %s", name, schedule.Cron)
+		return nil
+	}
+	if !isNotFound(err) {
+		return fmt.Errorf("failed to update schedule of %s: %w", name, err)
+	}
+
+	_, err = m.schedules.Create(ctx, client.ScheduleOptions{
+		ID:      ScheduleID(name),
+		Spec:    *spec,
+		Action:  action,
+		Overlap: overlap,
+		Memo:    map[string]interface{}{engine.ConfigNameMemoKey: name},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create schedule of %s: %w", name, err)
+	}
+
+	log.Printf("Created schedule of %s: %s", name, schedule.Cron)
+	return nil
+}
+
+// ReconcileSchedules сверяет расписания всех конфигураций, например после простоя сервиса.
+// Ошибка одной конфигурации не мешает сверить остальные.
+func (m *ConfigManager) ReconcileSchedules(ctx context.Context) error {
+	if m.schedules == nil {
+		return nil
+	}
+
+	names, err := m.repo.ListNames()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, name := range names {
+		if err := m.ReconcileSchedule(ctx, name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ListSchedules returns the Temporal Schedules of all configs
+func (m *ConfigManager) ListSchedules(ctx context.Context) ([]ScheduleSummary, error) {
+	if m.schedules == nil {
+		return nil, ErrSchedulesDisabled
+	}
+
+	iter, err := m.schedules.List(ctx, client.ScheduleListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schedules: %w", err)
+	}
+
+	summaries := []ScheduleSummary{}
+	for iter.HasNext() {
+		entry, err := iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list schedules: %w", err)
+		}
+		if !strings.HasPrefix(entry.ID, schedulePrefix) {
+			continue
+		}
+
+		summary := ScheduleSummary{
+			ConfigName:      strings.TrimPrefix(entry.ID, schedulePrefix),
+			ScheduleID:      entry.ID,
+			Paused:          entry.Paused,
+			Note:            entry.Note,
+			NextActionTimes: entry.NextActionTimes,
+			RecentActions:   make([]time.Time, 0, len(entry.RecentActions)),
+		}
+		if entry.Spec != nil {
+			summary.Cron = entry.Spec.CronExpressions
+			summary.TimeZone = entry.Spec.TimeZoneName
+		}
+		for _, action := range entry.RecentActions {
+			summary.RecentActions = append(summary.RecentActions, action.ActualTime)
+		}
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, nil
+}
+
+// PauseSchedule pauses the schedule of a config until it is unpaused, config updates keep the pause
+func (m *ConfigManager) PauseSchedule(ctx context.Context, name, note string) error {
+	return m.withSchedule(ctx, name, func(handle client.ScheduleHandle) error {
+		return handle.Pause(ctx, client.SchedulePauseOptions{Note: note})
+	})
+}
+
+// UnpauseSchedule resumes a paused schedule of a config
+func (m *ConfigManager) UnpauseSchedule(ctx context.Context, name, note string) error {
+	return m.withSchedule(ctx, name, func(handle client.ScheduleHandle) error {
+		return handle.Unpause(ctx, client.ScheduleUnpauseOptions{Note: note})
+	})
+}
+
+// TriggerSchedule starts a run of the scheduled config right now, the overlap policy of the schedule applies
+func (m *ConfigManager) TriggerSchedule(ctx context.Context, name string) error {
+	return m.withSchedule(ctx, name, func(handle client.ScheduleHandle) error {
+		return handle.Trigger(ctx, client.ScheduleTriggerOptions{})
+	})
+}
+
+// DeleteSchedule deletes the schedule of a config.
+// It is created again the next time a version with schedule is activated.
+func (m *ConfigManager) DeleteSchedule(ctx context.Context, name string) error {
+	return m.withSchedule(ctx, name, func(handle client.ScheduleHandle) error {
+		return handle.Delete(ctx)
+	})
+}
+
+// withSchedule выполняет операцию над расписанием конфигурации и переводит NotFound в ErrScheduleNotFound
+func (m *ConfigManager) withSchedule(ctx context.Context, name string, op func(handle client.ScheduleHandle) error) error {
+	if m.schedules == nil {
+		return ErrSchedulesDisabled
+	}
+
+	err := op(m.schedules.GetHandle(ctx, ScheduleID(name)))
+	if isNotFound(err) {
+		return ErrScheduleNotFound
+	}
+	return err
+}
+
+// scheduleOptions переводит schedule определения в спецификацию и действие Temporal Schedule.
+// Запуски выполняют ту же конфигурацию через DynamicTicketWorkflow, имя передается в memo.
+func scheduleOptions(name string, schedule engine.Schedule) (*client.ScheduleSpec, *client.ScheduleWorkflowAction, enumspb.ScheduleOverlapPolicy, error) {
+	overlap, err := schedule.OverlapPolicy()
+	if err != nil {
+		return nil, nil, overlap, err
+	}
+
+	spec := &client.ScheduleSpec{
+		CronExpressions: []string{schedule.Cron},
+		TimeZoneName:    schedule.TimeZone,
+	}
+	if schedule.Jitter != "" {
+		if spec.Jitter, err = time.ParseDuration(schedule.Jitter); err != nil {
+			return nil, nil, overlap, fmt.Errorf("invalid jitter: %w", err)
+		}
+	}
+
+	input, err := schedule.WorkflowInput()
+	if err != nil {
+		return nil, nil, overlap, err
+	}
+
+	action := &client.ScheduleWorkflowAction{
+		ID:        "scheduled-" + name,
+		Workflow:  engine.DynamicWorkflowType,
+		Args:      []interface{}{input},
+		TaskQueue: scheduleTaskQueue,
+		Memo:      map[string]interface{}{engine.ConfigNameMemoKey: name},
+	}
+
+	return spec, action, overlap, nil
+}
+
+func isNotFound(err error) bool {
+	var notFound *serviceerror.NotFound
+	return errors.As(err, &notFound)
+}
+
+var (
+	ErrSchedulesDisabled = errors.New("schedules are not configured")
+	ErrScheduleNotFound  = errors.New("schedule not found")
+)
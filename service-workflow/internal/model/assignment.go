@@ -0,0 +1,8 @@
+package model
+
+// Assignment - payload сигнала назначения тикета на агента
+type Assignment struct {
+	TicketID    string
+	Agent       string
+	UserGroupID int64
+}
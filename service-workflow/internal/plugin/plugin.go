@@ -0,0 +1,71 @@
+// Package plugin подключает activity, которые выполняются во внешних процессах по gRPC
+// (proto/activity_provider.proto), без изменений в internal/activity.
+package plugin
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	act "github.com/aimustaev/service-workflow/internal/activity"
+	"github.com/aimustaev/service-workflow/internal/config"
+)
+
+// Providers - провайдеры activity из конфигурации
+type Providers []*Provider
+
+// Load connects to the configured providers and fetches their activities.
+// A provider that cannot be reached or described is logged and skipped, so one provider being down
+// does not stop the process; its activities stay unknown until the process is restarted.
+// Workflows that reach such an activity do not fail: the engine fails their workflow task,
+// and Temporal retries it until a worker that has the provider loaded picks it up.
+func Load(ctx context.Context, cfgs []config.ActivityProviderConfig) Providers {
+	loaded := make([]*Provider, len(cfgs))
+
+	// Провайдеры опрашиваются параллельно, чтобы старт ждал недоступные не дольше describeTimeout
+	var wg sync.WaitGroup
+	for i, cfg := range cfgs {
+		p, err := Dial(cfg)
+		if err != nil {
+			log.Printf("Skipping activity provider %s: %v", cfg.Name, err)
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, p *Provider) {
+			defer wg.Done()
+			if err := p.Describe(ctx); err != nil {
+				log.Printf("Skipping activity provider %s: %v", p.name, err)
+				p.Close()
+				return
+			}
+			loaded[i] = p
+		}(i, p)
+	}
+	wg.Wait()
+
+	providers := make(Providers, 0, len(loaded))
+	for _, p := range loaded {
+		if p != nil {
+			providers = append(providers, p)
+		}
+	}
+	return providers
+}
+
+// Register регистрирует activity всех провайдеров в реестре
+func (ps Providers) Register(registry *act.Registry) error {
+	for _, p := range ps {
+		if err := p.Register(registry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes connections to all providers
+func (ps Providers) Close() {
+	for _, p := range ps {
+		p.Close()
+	}
+}
@@ -0,0 +1,193 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	act "github.com/aimustaev/service-workflow/internal/activity"
+	"github.com/aimustaev/service-workflow/internal/config"
+	"github.com/aimustaev/service-workflow/internal/generated/proto"
+)
+
+// Типы ошибок activity провайдеров, их можно указать в catch.errorTypes и retry.nonRetryableErrors
+const (
+	ProviderErrorType            = "ActivityProviderError"        // Провайдер вернул ошибку без типа
+	ProviderUnavailableErrorType = "ActivityProviderUnavailable"  // Провайдер недоступен или не ответил - повторяется
+	ProviderRequestErrorType     = "ActivityProviderRequestError" // Провайдер отклонил запрос или ответил не JSON - не повторяется
+)
+
+// describeTimeout ограничивает ожидание провайдера при старте, пока он поднимается вместе с сервисом
+const describeTimeout = 30 * time.Second
+
+// Provider - подключение к внешнему процессу, реализующему gRPC-сервис ActivityProvider
+type Provider struct {
+	name       string
+	conn       *grpc.ClientConn
+	client     proto.ActivityProviderClient
+	activities []act.Definition // Activity, которые провайдер описал при загрузке
+}
+
+// Dial подключается к провайдеру. Соединение устанавливается лениво, провайдер может быть еще не запущен.
+func Dial(cfg config.ActivityProviderConfig) (*Provider, error) {
+	if cfg.Name == "" || strings.Contains(cfg.Name, ".") {
+		return nil, fmt.Errorf("invalid activity provider name %q", cfg.Name)
+	}
+
+	conn, err := grpc.Dial(
+		cfg.Addr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to activity provider %s: %w", cfg.Name, err)
+	}
+
+	return &Provider{
+		name:   cfg.Name,
+		conn:   conn,
+		client: proto.NewActivityProviderClient(conn),
+	}, nil
+}
+
+// Close closes the gRPC connection
+func (p *Provider) Close() error {
+	return p.conn.Close()
+}
+
+// Describe запрашивает у провайдера его activity под именами "<provider>.<activity>".
+// Ждет, пока провайдер поднимется, но не дольше describeTimeout.
+func (p *Provider) Describe(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, describeTimeout)
+	defer cancel()
+
+	resp, err := p.client.Describe(ctx, &proto.DescribeRequest{}, grpc.WaitForReady(true))
+	if err != nil {
+		return fmt.Errorf("failed to describe activity provider %s: %w", p.name, err)
+	}
+
+	activities := make([]act.Definition, 0, len(resp.GetActivities()))
+	for _, desc := range resp.GetActivities() {
+		def := act.Definition{
+			Name:        p.name + "." + desc.GetName(),
+			Description: desc.GetDescription(),
+			Fn:          p.activity(desc.GetName()),
+		}
+		// Провайдер описывает единственный вход, а каталог ждет схему массива аргументов
+		if schema := desc.GetInputSchema(); schema != "" {
+			if !json.Valid([]byte(schema)) {
+				return fmt.Errorf("activity %s: invalid input schema", def.Name)
+			}
+			def.InputSchema = json.RawMessage(`{"type": "array", "items": [` + schema + `], "minItems": 1}`)
+		}
+		if schema := desc.GetOutputSchema(); schema != "" {
+			if !json.Valid([]byte(schema)) {
+				return fmt.Errorf("activity %s: invalid output schema", def.Name)
+			}
+			def.OutputSchema = json.RawMessage(schema)
+		}
+		activities = append(activities, def)
+	}
+
+	p.activities = activities
+	return nil
+}
+
+// Register добавляет в реестр activity, полученные в Describe
+func (p *Provider) Register(registry *act.Registry) error {
+	for _, def := range p.activities {
+		if err := registry.Register(def); err != nil {
+			return err
+		}
+	}
+	log.Printf("Registered %d activities of provider %s", len(p.activities), p.name)
+	return nil
+}
+
+// activity возвращает функцию activity, которая выполняет name на провайдере.
+// Вход и результат передаются как JSON, поэтому их тип - interface{}.
+func (p *Provider) activity(name string) func(ctx context.Context, input interface{}) (interface{}, error) {
+	return func(ctx context.Context, input interface{}) (interface{}, error) {
+		return p.execute(ctx, name, input)
+	}
+}
+
+func (p *Provider) execute(ctx context.Context, name string, input interface{}) (interface{}, error) {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return nil, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("failed to encode input: %v", err), ProviderRequestErrorType, err)
+	}
+
+	info := activity.GetInfo(ctx)
+	resp, err := p.client.Execute(ctx, &proto.ExecuteRequest{
+		Activity:   name,
+		Input:      string(data),
+		WorkflowId: info.WorkflowExecution.ID,
+		RunId:      info.WorkflowExecution.RunID,
+		ActivityId: info.ActivityID,
+		Attempt:    info.Attempt,
+	})
+	if err != nil {
+		return nil, p.callError(name, err)
+	}
+
+	if e := resp.GetError(); e != nil {
+		return nil, activityError(e)
+	}
+
+	var output interface{}
+	if resp.GetOutput() != "" {
+		if err := json.Unmarshal([]byte(resp.GetOutput()), &output); err != nil {
+			return nil, temporal.NewNonRetryableApplicationError(
+				fmt.Sprintf("%s.%s returned invalid JSON: %v", p.name, name, err), ProviderRequestErrorType, err)
+		}
+	}
+	return output, nil
+}
+
+// callError классифицирует ошибку gRPC-вызова: временная недоступность провайдера повторяется,
+// отказ выполнить запрос - нет
+func (p *Provider) callError(name string, err error) error {
+	st := status.Convert(err)
+	msg := fmt.Sprintf("%s.%s: %s: %s", p.name, name, st.Code(), st.Message())
+
+	switch st.Code() {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted,
+		codes.Internal, codes.Unknown, codes.Canceled:
+		return temporal.NewApplicationError(msg, ProviderUnavailableErrorType)
+	default:
+		return temporal.NewNonRetryableApplicationError(msg, ProviderRequestErrorType, nil)
+	}
+}
+
+// activityError переводит ошибку, которую вернул провайдер, в ошибку activity с его типом и деталями
+func activityError(e *proto.ActivityError) error {
+	errType := e.GetType()
+	if errType == "" {
+		errType = ProviderErrorType
+	}
+
+	var details []interface{}
+	if e.GetDetails() != "" {
+		var value interface{}
+		if err := json.Unmarshal([]byte(e.GetDetails()), &value); err != nil {
+			value = e.GetDetails()
+		}
+		details = append(details, value)
+	}
+
+	if e.GetNonRetryable() {
+		return temporal.NewNonRetryableApplicationError(e.GetMessage(), errType, nil, details...)
+	}
+	return temporal.NewApplicationError(e.GetMessage(), errType, details...)
+}
@@ -0,0 +1,312 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	commonpb "go.temporal.io/api/common/v1"
+	enumspb "go.temporal.io/api/enums/v1"
+	historypb "go.temporal.io/api/history/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/worker"
+	workflow2 "go.temporal.io/sdk/workflow"
+
+	act "github.com/aimustaev/service-workflow/internal/activity"
+	"github.com/aimustaev/service-workflow/internal/engine"
+	"github.com/aimustaev/service-workflow/internal/manager_workflow"
+	"github.com/aimustaev/service-workflow/internal/workflow"
+)
+
+// maxReplayExecutions ограничивает число последних запущенных выполнений, истории которых воспроизводятся
+const maxReplayExecutions = 100
+
+// Результаты воспроизведения истории
+const (
+	ReplayCompatible   = "compatible"
+	ReplayIncompatible = "incompatible"
+	ReplaySkipped      = "skipped" // Выполнение не загрузит кандидата: другая конфигурация или закрепленная версия
+)
+
+// CompatibilityCandidate - версия конфигурации, которую собираются активировать
+type CompatibilityCandidate struct {
+	Name       string
+	Ref        manager_workflow.ConfigRef
+	Definition engine.WorkflowDefinition
+}
+
+// ExecutionHistory - история выполнения с сервера Temporal или из экспортированного файла
+type ExecutionHistory struct {
+	WorkflowID string
+	RunID      string
+	Source     string // Файл, из которого загружена история
+	History    *historypb.History
+}
+
+// ExecutionReplay - результат воспроизведения одной истории
+type ExecutionReplay struct {
+	WorkflowID string `json:"workflowId,omitempty"`
+	RunID      string `json:"runId,omitempty"`
+	Source     string `json:"source,omitempty"`
+	Status     string `json:"status"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+// CompatibilityReport - отчет о совместимости кандидата с запущенными выполнениями
+type CompatibilityReport struct {
+	Name       string            `json:"name"`
+	Version    string            `json:"version"`
+	Compatible bool              `json:"compatible"`
+	Replayed   int               `json:"replayed"`
+	Executions []ExecutionReplay `json:"executions"`
+}
+
+// CheckCompatibilityUseCase воспроизводит истории выполнений DynamicTicketWorkflow с кандидатом
+// через WorkflowReplayer и находит выполнения, которые после активации упадут с non-determinism
+type CheckCompatibilityUseCase struct {
+	temporalClient client.Client
+	definitions    workflow.DefinitionSource
+	activities     *act.Registry
+}
+
+// NewCheckCompatibilityUseCase создает проверку. temporalClient и definitions могут быть nil
+// при офлайн-проверке экспортированных историй.
+func NewCheckCompatibilityUseCase(temporalClient client.Client, definitions workflow.DefinitionSource, activities *act.Registry) *CheckCompatibilityUseCase {
+	return &CheckCompatibilityUseCase{
+		temporalClient: temporalClient,
+		definitions:    definitions,
+		activities:     activities,
+	}
+}
+
+// Execute проверяет кандидата на историях последних запущенных выполнений его конфигурации
+func (uc *CheckCompatibilityUseCase) Execute(ctx context.Context, candidate CompatibilityCandidate) (*CompatibilityReport, error) {
+	histories, err := uc.runningHistories(ctx, candidate.Name)
+	if err != nil {
+		return nil, err
+	}
+	return uc.Check(candidate, histories)
+}
+
+// Check воспроизводит переданные истории с кандидатом
+func (uc *CheckCompatibilityUseCase) Check(candidate CompatibilityCandidate, histories []ExecutionHistory) (*CompatibilityReport, error) {
+	replayer, err := worker.NewWorkflowReplayerWithOptions(worker.WorkflowReplayerOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create workflow replayer: %w", err)
+	}
+
+	// Воспроизводится тот же код, что выполняет воркер, но активной версией считается кандидат
+	source := &candidateSource{base: uc.definitions, candidate: candidate}
+	dynamicWorkflow := workflow.NewDynamicWorkflow(nil, nil, source, uc.activities, nil)
+	replayer.RegisterWorkflowWithOptions(dynamicWorkflow.Execute, workflow2.RegisterOptions{Name: engine.DynamicWorkflowType})
+
+	report := &CompatibilityReport{
+		Name:       candidate.Name,
+		Version:    candidate.Ref.Version,
+		Compatible: true,
+	}
+
+	for _, h := range histories {
+		result := ExecutionReplay{
+			WorkflowID: h.WorkflowID,
+			RunID:      h.RunID,
+			Source:     h.Source,
+			Status:     ReplayCompatible,
+		}
+
+		name, pinned, err := historyConfig(h.History)
+		switch {
+		case err != nil:
+			result.Status = ReplayIncompatible
+			result.Reason = err.Error()
+		case name != candidate.Name:
+			result.Status = ReplaySkipped
+			result.Reason = "execution runs config " + name
+		case pinned.ID != uuid.Nil && pinned != candidate.Ref:
+			result.Status = ReplaySkipped
+			result.Reason = fmt.Sprintf("execution is pinned to version %s@%s", pinned.ID, pinned.Version)
+		default:
+			report.Replayed++
+			if err := replayer.ReplayWorkflowHistory(discardLogger(), h.History); err != nil {
+				result.Status = ReplayIncompatible
+				result.Reason = err.Error()
+			}
+		}
+
+		if result.Status == ReplayIncompatible {
+			report.Compatible = false
+		}
+		report.Executions = append(report.Executions, result)
+	}
+
+	return report, nil
+}
+
+// runningHistories загружает истории последних запущенных выполнений конфигурации name
+func (uc *CheckCompatibilityUseCase) runningHistories(ctx context.Context, name string) ([]ExecutionHistory, error) {
+	if uc.temporalClient == nil {
+		return nil, fmt.Errorf("temporal client is not configured")
+	}
+
+	query := fmt.Sprintf("WorkflowType = '%s' AND ExecutionStatus = 'Running'", engine.DynamicWorkflowType)
+
+	var (
+		histories []ExecutionHistory
+		pageToken []byte
+	)
+	for len(histories) < maxReplayExecutions {
+		resp, err := uc.temporalClient.ListWorkflow(ctx, &workflowservice.ListWorkflowExecutionsRequest{
+			Query:         query,
+			NextPageToken: pageToken,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list running workflows: %w", err)
+		}
+
+		for _, info := range resp.GetExecutions() {
+			if len(histories) >= maxReplayExecutions {
+				break
+			}
+
+			// Memo выполнения уже содержит имя конфигурации, истории чужих конфигураций не загружаем
+			if executionConfigName(info.GetType().GetName(), info.GetMemo()) != name {
+				continue
+			}
+
+			execution := info.GetExecution()
+			history, err := uc.history(ctx, execution.GetWorkflowId(), execution.GetRunId())
+			if err != nil {
+				return nil, err
+			}
+			histories = append(histories, ExecutionHistory{
+				WorkflowID: execution.GetWorkflowId(),
+				RunID:      execution.GetRunId(),
+				History:    history,
+			})
+		}
+
+		pageToken = resp.GetNextPageToken()
+		if len(pageToken) == 0 {
+			break
+		}
+	}
+
+	return histories, nil
+}
+
+func (uc *CheckCompatibilityUseCase) history(ctx context.Context, workflowID, runID string) (*historypb.History, error) {
+	iter := uc.temporalClient.GetWorkflowHistory(ctx, workflowID, runID, false, enumspb.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT)
+
+	history := &historypb.History{}
+	for iter.HasNext() {
+		event, err := iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get history of %s: %w", workflowID, err)
+		}
+		history.Events = append(history.Events, event)
+	}
+	return history, nil
+}
+
+// historyConfig определяет по истории имя конфигурации и версию, за которой закреплено выполнение.
+// Обе берутся из memo: начального и обновленного через UpsertMemo.
+func historyConfig(history *historypb.History) (string, manager_workflow.ConfigRef, error) {
+	var ref manager_workflow.ConfigRef
+
+	events := history.GetEvents()
+	if len(events) == 0 {
+		return "", ref, fmt.Errorf("history is empty")
+	}
+	started := events[0].GetWorkflowExecutionStartedEventAttributes()
+	if started == nil {
+		return "", ref, fmt.Errorf("history does not start with WorkflowExecutionStarted")
+	}
+
+	workflowType := started.GetWorkflowType().GetName()
+	if workflowType != engine.DynamicWorkflowType {
+		return "", ref, fmt.Errorf("history is of workflow type %s", workflowType)
+	}
+
+	memo := make(map[string]*commonpb.Payload)
+	for k, v := range started.GetMemo().GetFields() {
+		memo[k] = v
+	}
+	for _, event := range events[1:] {
+		if attrs := event.GetWorkflowPropertiesModifiedEventAttributes(); attrs != nil {
+			for k, v := range attrs.GetUpsertedMemo().GetFields() {
+				memo[k] = v
+			}
+		}
+	}
+
+	name := executionConfigName(workflowType, &commonpb.Memo{Fields: memo})
+
+	var id string
+	if decodeMemo(memo, workflow.ConfigIDMemoKey, &id) {
+		parsed, err := uuid.Parse(id)
+		if err != nil {
+			return "", ref, fmt.Errorf("invalid %s memo: %w", workflow.ConfigIDMemoKey, err)
+		}
+		ref.ID = parsed
+		decodeMemo(memo, workflow.ConfigVersionMemoKey, &ref.Version)
+	}
+
+	return name, ref, nil
+}
+
+// executionConfigName повторяет правило DynamicWorkflow: имя из memo, иначе тип workflow
+func executionConfigName(workflowType string, memo *commonpb.Memo) string {
+	var name string
+	if decodeMemo(memo.GetFields(), engine.ConfigNameMemoKey, &name) {
+		return name
+	}
+	return workflowType
+}
+
+func decodeMemo(fields map[string]*commonpb.Payload, key string, value interface{}) bool {
+	payload, ok := fields[key]
+	if !ok {
+		return false
+	}
+	return converter.GetDefaultDataConverter().FromPayload(payload, value) == nil
+}
+
+// candidateSource подменяет кандидатом активную версию его конфигурации
+type candidateSource struct {
+	base      workflow.DefinitionSource // nil при офлайн-проверке
+	candidate CompatibilityCandidate
+}
+
+func (s *candidateSource) GetWorkflowDefinition(name string) (engine.WorkflowDefinition, error) {
+	if name == s.candidate.Name {
+		return s.candidate.Definition, nil
+	}
+	if s.base == nil {
+		return engine.WorkflowDefinition{}, manager_workflow.ErrConfigNotFound
+	}
+	return s.base.GetWorkflowDefinition(name)
+}
+
+func (s *candidateSource) GetActiveConfigRef(name string) (manager_workflow.ConfigRef, error) {
+	if name == s.candidate.Name {
+		return s.candidate.Ref, nil
+	}
+	if s.base == nil {
+		return manager_workflow.ConfigRef{}, manager_workflow.ErrConfigNotFound
+	}
+	return s.base.GetActiveConfigRef(name)
+}
+
+// GetWorkflowDefinitionVersion возвращает кандидата и для его собственной версии,
+// которой может еще не быть в БД; содержимое сохраненной версии не меняется
+func (s *candidateSource) GetWorkflowDefinitionVersion(id uuid.UUID, version string) (engine.WorkflowDefinition, error) {
+	if (manager_workflow.ConfigRef{ID: id, Version: version}) == s.candidate.Ref {
+		return s.candidate.Definition, nil
+	}
+	if s.base == nil {
+		return engine.WorkflowDefinition{}, manager_workflow.ErrConfigNotFound
+	}
+	return s.base.GetWorkflowDefinitionVersion(id, version)
+}
@@ -0,0 +1,133 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/xeipuuv/gojsonschema"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+
+	"github.com/aimustaev/service-workflow/internal/engine"
+	"github.com/aimustaev/service-workflow/internal/usertask"
+)
+
+// ErrTaskWorkflowClosed означает, что workflow задачи уже завершился и результат некому передать
+var ErrTaskWorkflowClosed = errors.New("workflow of the task is no longer running")
+
+// TaskPayloadError - результат задачи не соответствует formSchema
+type TaskPayloadError struct {
+	Problems []string
+}
+
+func (e *TaskPayloadError) Error() string {
+	return "payload does not match form schema: " + strings.Join(e.Problems, "; ")
+}
+
+type CompleteUserTaskInput struct {
+	TaskID  uuid.UUID
+	User    string
+	Payload json.RawMessage
+}
+
+type CompleteUserTaskUseCase struct {
+	tasks          usertask.Repository
+	temporalClient client.Client
+}
+
+func NewCompleteUserTaskUseCase(tasks usertask.Repository, temporalClient client.Client) *CompleteUserTaskUseCase {
+	return &CompleteUserTaskUseCase{
+		tasks:          tasks,
+		temporalClient: temporalClient,
+	}
+}
+
+// Execute проверяет результат по formSchema задачи, отмечает задачу выполненной и передает результат
+// workflow сигналом. Если сигнал не доставлен, задача снова становится доступной.
+func (uc *CompleteUserTaskUseCase) Execute(ctx context.Context, input CompleteUserTaskInput) (*usertask.Task, error) {
+	task, err := uc.tasks.Get(input.TaskID)
+	if err != nil {
+		return nil, err
+	}
+	if task == nil {
+		return nil, usertask.ErrTaskNotFound
+	}
+
+	var payload interface{}
+	if len(input.Payload) > 0 {
+		if err := json.Unmarshal(input.Payload, &payload); err != nil {
+			return nil, &TaskPayloadError{Problems: []string{err.Error()}}
+		}
+	}
+	if task.FormSchema != nil {
+		if err := validateTaskPayload(*task.FormSchema, payload); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode payload: %w", err)
+	}
+
+	// Переход в completed атомарен, поэтому результат одной задачи отправляется в workflow один раз
+	task, err = uc.tasks.Complete(input.TaskID, input.User, data)
+	if err != nil {
+		return nil, err
+	}
+
+	completion := engine.UserTaskCompletion{
+		TaskID:      task.ID.String(),
+		CompletedBy: input.User,
+		Payload:     payload,
+	}
+	err = uc.temporalClient.SignalWorkflow(ctx, task.WorkflowID, task.RunID, task.Signal, completion)
+	if err == nil {
+		return task, nil
+	}
+
+	log.Printf("Failed to signal workflow %s about task %s: %v", task.WorkflowID, task.ID, err)
+	if reopenErr := uc.tasks.Reopen(task.ID); reopenErr != nil {
+		log.Printf("Failed to reopen task %s: %v", task.ID, reopenErr)
+	}
+
+	var notFound *serviceerror.NotFound
+	if errors.As(err, &notFound) {
+		// Выполнение завершилось, не дождавшись задачи: закрываем ее, чтобы она не висела в списке
+		if expireErr := uc.tasks.Expire(task.ID); expireErr != nil {
+			log.Printf("Failed to expire task %s: %v", task.ID, expireErr)
+		}
+		return nil, ErrTaskWorkflowClosed
+	}
+	return nil, fmt.Errorf("failed to signal workflow: %w", err)
+}
+
+// validateTaskPayload проверяет результат задачи по formSchema
+func validateTaskPayload(schema json.RawMessage, payload interface{}) error {
+	compiled, err := engine.CompileSchema(schema)
+	if err != nil {
+		return fmt.Errorf("invalid form schema: %w", err)
+	}
+	if compiled == nil {
+		return nil
+	}
+
+	result, err := compiled.Validate(gojsonschema.NewGoLoader(payload))
+	if err != nil {
+		return &TaskPayloadError{Problems: []string{err.Error()}}
+	}
+	if result.Valid() {
+		return nil
+	}
+
+	problems := make([]string, 0, len(result.Errors()))
+	for _, e := range result.Errors() {
+		problems = append(problems, e.String())
+	}
+	return &TaskPayloadError{Problems: problems}
+}
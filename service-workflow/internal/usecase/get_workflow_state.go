@@ -0,0 +1,56 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"go.temporal.io/sdk/client"
+
+	"github.com/aimustaev/service-workflow/internal/engine"
+)
+
+type GetWorkflowStateOutput struct {
+	WorkflowID   string                     `json:"workflow_id"`
+	CurrentState string                     `json:"current_state"`
+	State        map[string]json.RawMessage `json:"state"`
+	History      []engine.StateVisit        `json:"history"`
+}
+
+type GetWorkflowStateUseCase struct {
+	temporalClient client.Client
+}
+
+func NewGetWorkflowStateUseCase(temporalClient client.Client) *GetWorkflowStateUseCase {
+	return &GetWorkflowStateUseCase{
+		temporalClient: temporalClient,
+	}
+}
+
+// Execute опрашивает query handlers движка для последнего запуска workflow
+func (uc *GetWorkflowStateUseCase) Execute(ctx context.Context, workflowID string) (*GetWorkflowStateOutput, error) {
+	output := &GetWorkflowStateOutput{
+		WorkflowID: workflowID,
+	}
+
+	if err := uc.query(ctx, workflowID, engine.QueryCurrentState, &output.CurrentState); err != nil {
+		return nil, err
+	}
+	if err := uc.query(ctx, workflowID, engine.QueryState, &output.State); err != nil {
+		return nil, err
+	}
+	if err := uc.query(ctx, workflowID, engine.QueryHistory, &output.History); err != nil {
+		return nil, err
+	}
+
+	return output, nil
+}
+
+func (uc *GetWorkflowStateUseCase) query(ctx context.Context, workflowID, queryType string, result interface{}) error {
+	value, err := uc.temporalClient.QueryWorkflow(ctx, workflowID, "", queryType)
+	if err != nil {
+		log.Printf("Error querying workflow %s (%s): %v", workflowID, queryType, err)
+		return err
+	}
+	return value.Get(result)
+}
@@ -0,0 +1,352 @@
+package usecase
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	activity2 "go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/log"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+	"google.golang.org/protobuf/encoding/protojson"
+	protov2 "google.golang.org/protobuf/proto"
+
+	act "github.com/aimustaev/service-workflow/internal/activity"
+	"github.com/aimustaev/service-workflow/internal/engine"
+)
+
+// NotSimulatedErrorType - тип ошибки activity с результатом, для которой не задан результат симуляции
+const NotSimulatedErrorType = "NotSimulated"
+
+// ErrInvalidSimulation означает некорректный сценарий симуляции: неизвестную activity, результат
+// не того формата или неверное время сигнала
+var ErrInvalidSimulation = errors.New("invalid simulation")
+
+// simulationIdleTimeout - сколько реального времени ждать, если workflow заблокирован без таймеров
+// (например, ждет сигнал без таймаута, который не был передан)
+const simulationIdleTimeout = 3 * time.Second
+
+// SimulatedActivity - заготовленный результат activity
+type SimulatedActivity struct {
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *SimulatedError `json:"error,omitempty"` // Если задано, activity завершается этой ошибкой без повторов
+}
+
+// SimulatedError - ошибка activity, тип можно перехватить через catch.errorTypes
+type SimulatedError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// SimulatedSignal - сигнал, который отправляется в workflow во время симуляции
+type SimulatedSignal struct {
+	Name    string          `json:"name"`
+	After   string          `json:"after,omitempty"` // Через сколько времени workflow после старта, например "2h"
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+type SimulateWorkflowInput struct {
+	Input      map[string]interface{}       `json:"input"`
+	Activities map[string]SimulatedActivity `json:"activities,omitempty"` // Имя activity -> результат
+	Signals    []SimulatedSignal            `json:"signals,omitempty"`
+}
+
+// SimulationStep - выполненное состояние с вычисленным входом и записанными в state ключами
+type SimulationStep struct {
+	Workflow  string                     `json:"workflow"` // Конфигурация, для subworkflow - дочерняя
+	Name      string                     `json:"name"`
+	Type      string                     `json:"type"`
+	Input     interface{}                `json:"input,omitempty"`
+	Output    map[string]json.RawMessage `json:"output,omitempty"`
+	Error     string                     `json:"error,omitempty"`
+	StartedAt time.Time                  `json:"startedAt"`
+	EndedAt   *time.Time                 `json:"endedAt,omitempty"`
+}
+
+type SimulateWorkflowOutput struct {
+	Status string                     `json:"status"` // completed или failed
+	Error  string                     `json:"error,omitempty"`
+	Result interface{}                `json:"result,omitempty"`
+	Trace  []SimulationStep           `json:"trace"`
+	State  map[string]json.RawMessage `json:"state"`
+}
+
+// DefinitionSource returns active workflow definitions by config name
+type DefinitionSource interface {
+	GetWorkflowDefinition(name string) (engine.WorkflowDefinition, error)
+}
+
+// SimulateWorkflowUseCase выполняет определение в тестовом окружении Temporal без сервера:
+// activity заменяются заготовленными результатами, таймеры пропускаются, сигналы отправляются по сценарию
+type SimulateWorkflowUseCase struct {
+	activities  *act.Registry
+	definitions DefinitionSource
+	calendars   engine.CalendarSource
+}
+
+func NewSimulateWorkflowUseCase(activities *act.Registry, definitions DefinitionSource, calendars engine.CalendarSource) *SimulateWorkflowUseCase {
+	return &SimulateWorkflowUseCase{
+		activities:  activities,
+		definitions: definitions,
+		calendars:   calendars,
+	}
+}
+
+// Execute симулирует активную версию конфигурации name
+func (uc *SimulateWorkflowUseCase) Execute(name string, input SimulateWorkflowInput) (*SimulateWorkflowOutput, error) {
+	def, err := uc.definitions.GetWorkflowDefinition(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workflow definition: %w", err)
+	}
+	return uc.Simulate(name, def, input)
+}
+
+// Simulate симулирует переданное определение. Падение самого workflow не является ошибкой
+// и отражается в Status и Error результата.
+func (uc *SimulateWorkflowUseCase) Simulate(name string, def engine.WorkflowDefinition, input SimulateWorkflowInput) (output *SimulateWorkflowOutput, err error) {
+	var suite testsuite.WorkflowTestSuite
+	suite.SetLogger(discardLogger())
+
+	env := suite.NewTestWorkflowEnvironment()
+	env.SetTestTimeout(simulationIdleTimeout)
+
+	if err := uc.registerActivities(env, input.Activities); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSimulation, err)
+	}
+	if err := scheduleSignals(env, input.Signals); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSimulation, err)
+	}
+
+	// Дочерние workflow имеют тот же тип, что и корневой, поэтому регистрируется одна функция
+	sim := &simulation{uc: uc, rootName: name, root: def}
+	env.RegisterWorkflowWithOptions(sim.execute, workflow.RegisterOptions{Name: engine.DynamicWorkflowType})
+
+	// Тестовое окружение сообщает о нарушениях паникой
+	defer func() {
+		if r := recover(); r != nil {
+			output, err = nil, fmt.Errorf("simulation failed: %v", r)
+		}
+	}()
+
+	env.ExecuteWorkflow(engine.DynamicWorkflowType, input.Input)
+
+	output = &SimulateWorkflowOutput{
+		Status: "completed",
+		Trace:  sim.steps,
+	}
+	if wfErr := env.GetWorkflowError(); wfErr != nil {
+		output.Status = "failed"
+		output.Error = wfErr.Error()
+	} else if err := env.GetWorkflowResult(&output.Result); err != nil && !errors.Is(err, temporal.ErrNoData) {
+		return nil, fmt.Errorf("failed to decode workflow result: %w", err)
+	}
+
+	if value, err := env.QueryWorkflow(engine.QueryState); err == nil {
+		if err := value.Get(&output.State); err != nil {
+			return nil, fmt.Errorf("failed to decode workflow state: %w", err)
+		}
+	}
+
+	return output, nil
+}
+
+// discardLogger - логгер Temporal для выполнений внутри сервиса, их логи никуда не пишутся
+func discardLogger() log.Logger {
+	return log.NewStructuredLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+// scheduleSignals отправляет сигналы сценария в заданное время workflow
+func scheduleSignals(env *testsuite.TestWorkflowEnvironment, signals []SimulatedSignal) error {
+	for _, signal := range signals {
+		if signal.Name == "" {
+			return fmt.Errorf("signal name is required")
+		}
+
+		var after time.Duration
+		if signal.After != "" {
+			var err error
+			if after, err = time.ParseDuration(signal.After); err != nil {
+				return fmt.Errorf("signal %s: invalid after: %w", signal.Name, err)
+			}
+		}
+
+		signal := signal
+		env.RegisterDelayedCallback(func() {
+			env.SignalWorkflow(signal.Name, signal.Payload)
+		}, after)
+	}
+	return nil
+}
+
+// registerActivities регистрирует вместо каждой activity реестра функцию с той же сигнатурой,
+// которая возвращает заготовленный результат
+func (uc *SimulateWorkflowUseCase) registerActivities(env *testsuite.TestWorkflowEnvironment, canned map[string]SimulatedActivity) error {
+	names := make([]string, 0, len(canned))
+	for name := range canned {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := uc.activities.Get(name); !ok {
+			return fmt.Errorf("unknown activity: %s", name)
+		}
+	}
+
+	for _, def := range uc.activities.List() {
+		fn, err := simulatedActivity(def, canned[def.Name])
+		if err != nil {
+			return fmt.Errorf("activity %s: %w", def.Name, err)
+		}
+		env.RegisterActivityWithOptions(fn, activity2.RegisterOptions{Name: def.Name})
+	}
+	return nil
+}
+
+var errorInterface = reflect.TypeOf((*error)(nil)).Elem()
+
+// simulatedActivity строит функцию с сигнатурой def.Fn. Activity без результата по умолчанию
+// завершаются успешно, activity с результатом без заготовки - ошибкой NotSimulated.
+func simulatedActivity(def *act.Definition, canned SimulatedActivity) (interface{}, error) {
+	outputType := def.OutputType()
+
+	var result reflect.Value
+	if outputType != nil {
+		result = reflect.Zero(outputType)
+		if len(canned.Result) > 0 {
+			value, err := decodeResult(canned.Result, outputType)
+			if err != nil {
+				return nil, fmt.Errorf("invalid result: %w", err)
+			}
+			result = value
+		}
+	}
+
+	var activityErr error
+	switch {
+	case canned.Error != nil:
+		activityErr = temporal.NewNonRetryableApplicationError(canned.Error.Message, canned.Error.Type, nil)
+	case outputType != nil && len(canned.Result) == 0:
+		activityErr = temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("no simulated result for activity %s", def.Name), NotSimulatedErrorType, nil)
+	}
+
+	fn := reflect.MakeFunc(reflect.TypeOf(def.Fn), func([]reflect.Value) []reflect.Value {
+		errValue := reflect.Zero(errorInterface)
+		if activityErr != nil {
+			errValue = reflect.ValueOf(&activityErr).Elem()
+		}
+		if outputType == nil {
+			return []reflect.Value{errValue}
+		}
+		return []reflect.Value{result, errValue}
+	})
+	return fn.Interface(), nil
+}
+
+var protoMessageType = reflect.TypeOf((*protov2.Message)(nil)).Elem()
+
+// decodeResult декодирует результат в тип activity; protobuf-сообщения - через protojson, как в state
+func decodeResult(data json.RawMessage, t reflect.Type) (reflect.Value, error) {
+	if t.Kind() == reflect.Ptr && t.Implements(protoMessageType) {
+		ptr := reflect.New(t.Elem())
+		if err := protojson.Unmarshal(data, ptr.Interface().(protov2.Message)); err != nil {
+			return reflect.Value{}, err
+		}
+		return ptr, nil
+	}
+
+	ptr := reflect.New(t)
+	if err := json.Unmarshal(data, ptr.Interface()); err != nil {
+		return reflect.Value{}, err
+	}
+	return ptr.Elem(), nil
+}
+
+// simulation собирает трассировку корневого и дочерних workflow
+type simulation struct {
+	uc       *SimulateWorkflowUseCase
+	rootName string
+	root     engine.WorkflowDefinition
+
+	mu    sync.Mutex
+	steps []SimulationStep
+	// Конфигурации запущенных subworkflow в порядке запуска. Тестовое окружение не передает
+	// дочерним workflow memo с именем конфигурации, а запускает их в порядке вызова ExecuteChildWorkflow.
+	children []string
+}
+
+// execute - функция workflow для корневого и дочерних запусков
+func (s *simulation) execute(ctx workflow.Context, input map[string]interface{}) (interface{}, error) {
+	name, def := s.rootName, s.root
+	if workflow.GetInfo(ctx).ParentWorkflowExecution != nil {
+		var err error
+		name = s.nextChild()
+		if def, err = s.uc.definitions.GetWorkflowDefinition(name); err != nil {
+			return nil, fmt.Errorf("failed to get workflow definition %s: %w", name, err)
+		}
+	}
+
+	eng := engine.NewEngine(nil, s.uc.activities).
+		WithTracer(&simulationTracer{sim: s, workflow: name}).
+		WithCalendars(s.uc.calendars)
+	return eng.ExecuteWorkflow(ctx, def, input)
+}
+
+func (s *simulation) nextChild() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.children) == 0 {
+		return ""
+	}
+	name := s.children[0]
+	s.children = s.children[1:]
+	return name
+}
+
+// simulationTracer записывает шаги одного workflow в общую трассировку
+type simulationTracer struct {
+	sim      *simulation
+	workflow string
+}
+
+func (t *simulationTracer) StateStarted(ctx workflow.Context, def engine.StateDefinition, input interface{}) func(map[string]json.RawMessage, error) {
+	s := t.sim
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Дочерний workflow запускается, только если input subworkflow удалось вычислить
+	if def.Type == "subworkflow" && input != nil {
+		s.children = append(s.children, def.Workflow)
+	}
+
+	idx := len(s.steps)
+	s.steps = append(s.steps, SimulationStep{
+		Workflow:  t.workflow,
+		Name:      def.Name,
+		Type:      def.Type,
+		Input:     input,
+		StartedAt: workflow.Now(ctx),
+	})
+
+	return func(output map[string]json.RawMessage, err error) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		now := workflow.Now(ctx)
+		step := &s.steps[idx]
+		step.Output = output
+		step.EndedAt = &now
+		if err != nil {
+			step.Error = err.Error()
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package usertask
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"go.temporal.io/sdk/temporal"
+
+	act "github.com/aimustaev/service-workflow/internal/activity"
+	"github.com/aimustaev/service-workflow/internal/engine"
+)
+
+// Activities сохраняют задачи состояний userTask. Их имена фиксированы движком,
+// в activityName определений они не используются.
+type Activities struct {
+	repo Repository
+}
+
+// RegisterActivities adds the activities of userTask states to the registry.
+// repo may be nil when the registry is only used for validation, simulation or replay.
+func RegisterActivities(registry *act.Registry, repo Repository) {
+	a := &Activities{repo: repo}
+
+	registry.MustRegister(act.Definition{
+		Name:        engine.CreateUserTaskActivity,
+		Description: "Сохранить задачу состояния userTask",
+		Fn:          a.CreateUserTaskActivity,
+	})
+	registry.MustRegister(act.Definition{
+		Name:        engine.ExpireUserTaskActivity,
+		Description: "Закрыть задачу состояния userTask по истечении срока",
+		Fn:          a.ExpireUserTaskActivity,
+	})
+}
+
+// CreateUserTaskActivity сохраняет задачу, созданную состоянием userTask
+func (a *Activities) CreateUserTaskActivity(ctx context.Context, record engine.UserTaskRecord) error {
+	id, err := uuid.Parse(record.ID)
+	if err != nil {
+		return temporal.NewNonRetryableApplicationError(fmt.Sprintf("invalid task id %q", record.ID), "InvalidUserTask", err)
+	}
+
+	task := &Task{
+		ID:          id,
+		WorkflowID:  record.WorkflowID,
+		RunID:       record.RunID,
+		State:       record.State,
+		Signal:      record.Signal,
+		Title:       record.Title,
+		Description: record.Description,
+		Assignee:    record.Assignee,
+		Group:       record.Group,
+		TicketID:    record.TicketID,
+		DueAt:       record.DueAt,
+	}
+	if len(record.FormSchema) > 0 {
+		task.FormSchema = &record.FormSchema
+	}
+
+	return a.repo.Create(task)
+}
+
+// ExpireUserTaskActivity закрывает задачу по истечении срока. Если задачу успели выполнить,
+// возвращает ошибку UserTaskCompleted, и движок ждет сигнал с ее результатом.
+func (a *Activities) ExpireUserTaskActivity(ctx context.Context, taskID string) error {
+	id, err := uuid.Parse(taskID)
+	if err != nil {
+		return temporal.NewNonRetryableApplicationError(fmt.Sprintf("invalid task id %q", taskID), "InvalidUserTask", err)
+	}
+
+	err = a.repo.Expire(id)
+	if errors.Is(err, ErrTaskCompleted) {
+		return temporal.NewNonRetryableApplicationError(err.Error(), engine.UserTaskCompletedErrorType, nil)
+	}
+	return err
+}
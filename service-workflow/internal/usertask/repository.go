@@ -0,0 +1,219 @@
+package usertask
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const taskColumns = `id, workflow_id, run_id, state_name, signal_name, title, description, assignee, group_name,
+		ticket_id, form_schema, due_at, status, claimed_by, completed_by, result, created_at, updated_at, completed_at`
+
+// PostgresRepository implements Repository using PostgreSQL
+type PostgresRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresRepository creates a new instance of PostgresRepository
+func NewPostgresRepository(db *sqlx.DB) *PostgresRepository {
+	return &PostgresRepository{db: db}
+}
+
+// Create saves a new task, saving the same task again does nothing.
+// Activity может выполниться повторно, поэтому вставка идемпотентна по ID.
+func (r *PostgresRepository) Create(task *Task) error {
+	query := `
+		INSERT INTO configs.user_tasks (
+			id, workflow_id, run_id, state_name, signal_name, title, description, assignee, group_name,
+			ticket_id, form_schema, due_at, status, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $14
+		)
+		ON CONFLICT (id) DO NOTHING
+	`
+
+	task.Status = StatusOpen
+	task.CreatedAt = time.Now()
+	task.UpdatedAt = task.CreatedAt
+
+	_, err := r.db.Exec(query,
+		task.ID,
+		task.WorkflowID,
+		task.RunID,
+		task.State,
+		task.Signal,
+		task.Title,
+		task.Description,
+		task.Assignee,
+		task.Group,
+		task.TicketID,
+		task.FormSchema,
+		task.DueAt,
+		task.Status,
+		task.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create task: %w", err)
+	}
+
+	return nil
+}
+
+// Get returns a task by ID or nil if it does not exist
+func (r *PostgresRepository) Get(id uuid.UUID) (*Task, error) {
+	query := `SELECT ` + taskColumns + ` FROM configs.user_tasks WHERE id = $1`
+
+	var task Task
+	err := r.db.Get(&task, query, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+
+	return &task, nil
+}
+
+// ListOpen returns open and claimed tasks matching the filter, the earliest due first
+func (r *PostgresRepository) ListOpen(filter Filter) ([]*Task, error) {
+	conditions := []string{"status IN ('open', 'claimed')"}
+	var args []interface{}
+	argCount := 1
+
+	if filter.Assignee != "" {
+		conditions = append(conditions, fmt.Sprintf("(assignee = $%d OR claimed_by = $%d)", argCount, argCount))
+		args = append(args, filter.Assignee)
+		argCount++
+	}
+
+	if filter.Group != "" {
+		conditions = append(conditions, fmt.Sprintf("group_name = $%d", argCount))
+		args = append(args, filter.Group)
+		argCount++
+	}
+
+	if filter.TicketID != "" {
+		conditions = append(conditions, fmt.Sprintf("ticket_id = $%d", argCount))
+		args = append(args, filter.TicketID)
+		argCount++
+	}
+
+	query := `SELECT ` + taskColumns + ` FROM configs.user_tasks
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY due_at ASC NULLS LAST, created_at ASC`
+
+	var tasks []*Task
+	if err := r.db.Select(&tasks, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %w", err)
+	}
+
+	return tasks, nil
+}
+
+// Claim assigns an open task to user. Задачу с назначенным исполнителем может взять только он,
+// повторный claim тем же пользователем ничего не меняет.
+func (r *PostgresRepository) Claim(id uuid.UUID, user string) (*Task, error) {
+	query := `
+		UPDATE configs.user_tasks
+		SET status = 'claimed', claimed_by = $2, updated_at = $3
+		WHERE id = $1 AND (
+			(status = 'open' AND (assignee = '' OR assignee = $2))
+			OR (status = 'claimed' AND claimed_by = $2)
+		)
+		RETURNING ` + taskColumns
+
+	return r.transition(id, query, id, user, time.Now())
+}
+
+// Complete marks a task completed by user. Открытую задачу может выполнить назначенный исполнитель
+// (или любой, если исполнитель не назначен), взятую - только взявший ее.
+func (r *PostgresRepository) Complete(id uuid.UUID, user string, result json.RawMessage) (*Task, error) {
+	query := `
+		UPDATE configs.user_tasks
+		SET status = 'completed', completed_by = $2, result = $3, completed_at = $4, updated_at = $4
+		WHERE id = $1 AND (
+			(status = 'open' AND (assignee = '' OR assignee = $2))
+			OR (status = 'claimed' AND claimed_by = $2)
+		)
+		RETURNING ` + taskColumns
+
+	return r.transition(id, query, id, user, result, time.Now())
+}
+
+// Reopen reverts a completion whose result could not be delivered to the workflow
+func (r *PostgresRepository) Reopen(id uuid.UUID) error {
+	query := `
+		UPDATE configs.user_tasks
+		SET status = CASE WHEN claimed_by = '' THEN 'open' ELSE 'claimed' END,
+			completed_by = '', result = NULL, completed_at = NULL, updated_at = $2
+		WHERE id = $1 AND status = 'completed'
+	`
+
+	if _, err := r.db.Exec(query, id, time.Now()); err != nil {
+		return fmt.Errorf("failed to reopen task: %w", err)
+	}
+	return nil
+}
+
+// Expire marks an open or claimed task expired. Повторный вызов для истекшей задачи ничего не меняет,
+// для выполненной возвращает ErrTaskCompleted.
+func (r *PostgresRepository) Expire(id uuid.UUID) error {
+	query := `
+		UPDATE configs.user_tasks
+		SET status = 'expired', updated_at = $2
+		WHERE id = $1 AND status IN ('open', 'claimed')
+	`
+
+	result, err := r.db.Exec(query, id, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to expire task: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows > 0 {
+		return nil
+	}
+
+	task, err := r.Get(id)
+	if err != nil {
+		return err
+	}
+	switch {
+	case task == nil:
+		return ErrTaskNotFound
+	case task.Status == StatusCompleted:
+		return ErrTaskCompleted
+	}
+	return nil
+}
+
+// transition выполняет условный UPDATE ... RETURNING; если строка не изменилась,
+// различает отсутствующую задачу и недоступную для перехода
+func (r *PostgresRepository) transition(id uuid.UUID, query string, args ...interface{}) (*Task, error) {
+	var task Task
+	err := r.db.Get(&task, query, args...)
+	if err == nil {
+		return &task, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to update task: %w", err)
+	}
+
+	existing, err := r.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, ErrTaskNotFound
+	}
+	return nil, ErrTaskNotAvailable
+}
@@ -0,0 +1,79 @@
+// Package usertask хранит задачи для людей, которые создают состояния userTask,
+// и выполняет activity, через которые движок их сохраняет.
+package usertask
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Статусы задачи
+const (
+	StatusOpen      = "open"      // Ждет исполнителя
+	StatusClaimed   = "claimed"   // Взята в работу, выполнить может только взявший
+	StatusCompleted = "completed" // Выполнена, workflow получил результат
+	StatusExpired   = "expired"   // Срок истек, workflow пошел по timeoutNext
+)
+
+// Task represents a human task stored in the database
+type Task struct {
+	ID          uuid.UUID        `json:"id" db:"id"`
+	WorkflowID  string           `json:"workflow_id" db:"workflow_id"`
+	RunID       string           `json:"run_id" db:"run_id"`
+	State       string           `json:"state" db:"state_name"`
+	Signal      string           `json:"-" db:"signal_name"`
+	Title       string           `json:"title" db:"title"`
+	Description string           `json:"description,omitempty" db:"description"`
+	Assignee    string           `json:"assignee,omitempty" db:"assignee"`
+	Group       string           `json:"group,omitempty" db:"group_name"`
+	TicketID    string           `json:"ticket_id,omitempty" db:"ticket_id"`
+	FormSchema  *json.RawMessage `json:"form_schema,omitempty" db:"form_schema"`
+	DueAt       *time.Time       `json:"due_at,omitempty" db:"due_at"`
+	Status      string           `json:"status" db:"status"`
+	ClaimedBy   string           `json:"claimed_by,omitempty" db:"claimed_by"`
+	CompletedBy string           `json:"completed_by,omitempty" db:"completed_by"`
+	Result      *json.RawMessage `json:"result,omitempty" db:"result"`
+	CreatedAt   time.Time        `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at" db:"updated_at"`
+	CompletedAt *time.Time       `json:"completed_at,omitempty" db:"completed_at"`
+}
+
+// Filter represents filters for listing open tasks, empty fields are not applied
+type Filter struct {
+	Assignee string
+	Group    string
+	TicketID string
+}
+
+// Repository defines the interface for working with tasks
+type Repository interface {
+	// Create saves a new task, saving the same task again does nothing
+	Create(task *Task) error
+
+	// Get returns a task by ID or nil if it does not exist
+	Get(id uuid.UUID) (*Task, error)
+
+	// ListOpen returns open and claimed tasks matching the filter, the earliest due first
+	ListOpen(filter Filter) ([]*Task, error)
+
+	// Claim assigns an open task to user
+	Claim(id uuid.UUID, user string) (*Task, error)
+
+	// Complete marks a task completed by user with the given result
+	Complete(id uuid.UUID, user string, result json.RawMessage) (*Task, error)
+
+	// Reopen reverts a completion whose result could not be delivered to the workflow
+	Reopen(id uuid.UUID) error
+
+	// Expire marks an open or claimed task expired
+	Expire(id uuid.UUID) error
+}
+
+var (
+	ErrTaskNotFound     = errors.New("task not found")
+	ErrTaskNotAvailable = errors.New("task is completed, expired or assigned to another user")
+	ErrTaskCompleted    = errors.New("task is already completed")
+)
@@ -0,0 +1,81 @@
+package validation
+
+import (
+	"strings"
+
+	"github.com/aimustaev/service-workflow/internal/engine"
+)
+
+// subworkflowRef - ссылка subworkflow-состояния на другую конфигурацию
+type subworkflowRef struct {
+	path string
+	name string
+}
+
+// checkSubworkflowCycles проверяет, что конфигурации из subworkflow существуют
+// и что по цепочке subworkflow нельзя вернуться к конфигурации name, под которой хранится проверяемое определение
+func (c *checker) checkSubworkflowCycles(name string) {
+	source := c.validator.definitions
+
+	for _, ref := range c.subworkflows {
+		if ref.name == name {
+			c.add(ref.path, "subworkflow cycle: %s -> %s", name, ref.name)
+			continue
+		}
+		if source == nil {
+			continue
+		}
+
+		target, err := source.GetWorkflowDefinition(ref.name)
+		if err != nil {
+			c.add(ref.path, "cannot resolve workflow %q: %v", ref.name, err)
+			continue
+		}
+
+		visited := map[string]struct{}{ref.name: {}}
+		if chain := findCycle(source, name, target, []string{name, ref.name}, visited); chain != nil {
+			c.add(ref.path, "subworkflow cycle: %s", strings.Join(chain, " -> "))
+		}
+	}
+}
+
+// findCycle обходит subworkflow конфигурации def в глубину и возвращает цепочку имен,
+// если она приводит к root. Недоступные конфигурации пропускаются - это ошибка их собственной валидации.
+func findCycle(source DefinitionSource, root string, def engine.WorkflowDefinition, chain []string, visited map[string]struct{}) []string {
+	for _, name := range subworkflowNames(def.States) {
+		next := append(append([]string(nil), chain...), name)
+		if name == root {
+			return next
+		}
+		if _, ok := visited[name]; ok {
+			continue
+		}
+		visited[name] = struct{}{}
+
+		child, err := source.GetWorkflowDefinition(name)
+		if err != nil {
+			continue
+		}
+		if cycle := findCycle(source, root, child, next, visited); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+// subworkflowNames возвращает имена конфигураций, которые запускают состояния, включая вложенные
+func subworkflowNames(states []engine.StateDefinition) []string {
+	var names []string
+	for _, state := range states {
+		if state.Type == "subworkflow" && state.Workflow != "" {
+			names = append(names, state.Workflow)
+		}
+		for _, branch := range state.Branches {
+			names = append(names, subworkflowNames(branch.States)...)
+		}
+		if state.Iterator != nil {
+			names = append(names, subworkflowNames(state.Iterator.States)...)
+		}
+	}
+	return names
+}
@@ -0,0 +1,321 @@
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aimustaev/service-workflow/internal/engine"
+)
+
+// checkStateRefs проверяет, что каждая ссылка "$.key..." в состоянии указывает на ключ state,
+// который может быть записан каким-либо состоянием на пути от начала до текущего.
+func (c *checker) checkStateRefs(path string, state engine.StateDefinition, keys map[string]struct{}) {
+	if state.Type == "subworkflow" || state.Type == "userTask" {
+		c.checkObjectRefs(path+".input", state.Input, keys)
+	} else {
+		c.checkInputRefs(path+".input", state.Input, keys)
+	}
+
+	// Значения set вычисляются по state до записи, поэтому ссылаться друг на друга не могут
+	for key, raw := range state.Values {
+		var value interface{}
+		if err := json.Unmarshal(raw, &value); err != nil {
+			c.add(path+".values."+key, "invalid value: %v", err)
+			continue
+		}
+		c.checkRef(path+".values."+key, value, keys)
+	}
+
+	for j, rule := range state.Choices {
+		c.checkConditionRefs(fmt.Sprintf("%s.choices[%d].condition", path, j), rule.Condition, keys)
+	}
+
+	// Actions выполняются после срабатывания сигнала или таймера, им доступны данные сигнала
+	actionKeys := withKeys(keys, producedKeys(state)...)
+	for j, action := range state.Actions {
+		c.checkInputRefs(fmt.Sprintf("%s.actions[%d].input", path, j), action.Input, actionKeys)
+	}
+	for j, event := range state.Events {
+		for k, action := range event.Actions {
+			c.checkInputRefs(fmt.Sprintf("%s.events[%d].actions[%d].input", path, j, k), action.Input, actionKeys)
+		}
+	}
+
+	if state.Compensate != nil {
+		c.checkInputRefs(path+".compensate.input", state.Compensate.Input, actionKeys)
+	}
+
+	if state.ItemsPath != "" {
+		c.checkRef(path+".itemsPath", state.ItemsPath, keys)
+	}
+	// Условие while вычисляется и после итераций, поэтому ему доступны ключи тела цикла
+	if state.Condition != nil {
+		c.checkConditionRefs(path+".condition", *state.Condition, withKeys(keys, loopKeys(state)...))
+	}
+}
+
+// loopKeys возвращает ключи, доступные внутри тела цикла: переменные цикла и записанные телом
+func loopKeys(state engine.StateDefinition) []string {
+	keys := []string{"index"}
+	if state.Type == "foreach" {
+		keys = append(keys, "item")
+	}
+	if state.Iterator != nil {
+		for _, s := range state.Iterator.States {
+			keys = append(keys, producedKeys(s)...)
+		}
+	}
+	return keys
+}
+
+// availableKeys вычисляет для каждого достижимого состояния множество ключей state,
+// которые могли быть записаны до его выполнения (объединение по всем путям графа)
+func (c *checker) availableKeys(s *scope, initial map[string]struct{}) map[int]map[string]struct{} {
+	start := 0
+	if s.startAt != "" {
+		idx, ok := s.names[s.startAt]
+		if !ok {
+			return nil
+		}
+		start = idx
+	}
+
+	available := map[int]map[string]struct{}{
+		start: withKeys(initial),
+	}
+	queue := []int{start}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		state := s.states[current]
+		out := withKeys(available[current], producedKeys(state)...)
+
+		for _, next := range s.successors(current) {
+			keys, seen := available[next]
+			if !seen {
+				keys = make(map[string]struct{})
+				available[next] = keys
+			}
+
+			changed := !seen
+			for k := range out {
+				if _, ok := keys[k]; !ok {
+					keys[k] = struct{}{}
+					changed = true
+				}
+			}
+			if changed {
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	return available
+}
+
+// successors повторяет правила переходов движка: choices/default, сигналы, next, catch и порядок по списку
+func (s *scope) successors(current int) []int {
+	state := s.states[current]
+	var targets []string
+
+	for _, rule := range state.Catch {
+		targets = append(targets, rule.Next)
+	}
+
+	// Обычный переход используется, если состояние не задает свой явно
+	defaultFlow := true
+	switch state.Type {
+	case "choice":
+		for _, rule := range state.Choices {
+			targets = append(targets, rule.Next)
+		}
+		targets = append(targets, state.Default)
+		defaultFlow = false
+
+	case "waitForSignal":
+		defaultFlow = false
+		for _, signal := range state.Signals {
+			if signal.Next == "" {
+				defaultFlow = true
+			}
+			targets = append(targets, signal.Next)
+		}
+		targets = append(targets, state.TimeoutNext)
+
+	case "userTask":
+		targets = append(targets, state.TimeoutNext)
+
+	case "select":
+		defaultFlow = false
+		for _, event := range state.Events {
+			if event.Next == "" {
+				defaultFlow = true
+			}
+			targets = append(targets, event.Next)
+		}
+	}
+
+	if defaultFlow && !state.End {
+		if state.Next != "" {
+			targets = append(targets, state.Next)
+		} else if current+1 < len(s.states) {
+			targets = append(targets, s.states[current+1].Name)
+		}
+	}
+
+	var result []int
+	for _, target := range targets {
+		if idx, ok := s.names[target]; ok {
+			result = append(result, idx)
+		}
+	}
+	return result
+}
+
+// producedKeys возвращает ключи state, которые может записать состояние
+func producedKeys(state engine.StateDefinition) []string {
+	var keys []string
+	if state.Output != "" {
+		keys = append(keys, state.Output)
+	}
+	if state.Type == "signal" {
+		keys = append(keys, "signalPayload")
+	}
+	for _, signal := range state.Signals {
+		if signal.Output != "" {
+			keys = append(keys, signal.Output)
+		}
+	}
+	for _, action := range state.Actions {
+		keys = append(keys, producedKeys(action)...)
+	}
+	if state.Type == "select" {
+		// Имя сработавшего события записывается по имени состояния, если output не задан
+		if state.Output == "" {
+			keys = append(keys, state.Name)
+		}
+		for _, event := range state.Events {
+			if event.Output != "" {
+				keys = append(keys, event.Output)
+			}
+			for _, action := range event.Actions {
+				keys = append(keys, producedKeys(action)...)
+			}
+		}
+	}
+	for _, rule := range state.Catch {
+		if rule.Output != "" {
+			keys = append(keys, rule.Output)
+		}
+	}
+	for _, branch := range state.Branches {
+		keys = append(keys, branch.Name)
+	}
+	for key := range state.OutputMapping {
+		keys = append(keys, key)
+	}
+	for key := range state.Values {
+		keys = append(keys, key)
+	}
+	// Тело while работает с общим state, его записи видны после цикла
+	if state.Type == "while" && state.Iterator != nil {
+		for _, s := range state.Iterator.States {
+			keys = append(keys, producedKeys(s)...)
+		}
+	}
+	return keys
+}
+
+func (c *checker) checkInputRefs(path string, input json.RawMessage, keys map[string]struct{}) {
+	if len(input) == 0 {
+		return
+	}
+
+	// Движок подставляет ссылки только в одиночное значение или элементы массива
+	var values []interface{}
+	if input[0] == '[' {
+		if err := json.Unmarshal(input, &values); err != nil {
+			c.add(path, "invalid input: %v", err)
+			return
+		}
+		for i, value := range values {
+			c.checkRef(fmt.Sprintf("%s[%d]", path, i), value, keys)
+		}
+		return
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(input, &value); err != nil {
+		c.add(path, "invalid input: %v", err)
+		return
+	}
+	c.checkRef(path, value, keys)
+}
+
+// checkObjectRefs проверяет input subworkflow - объект, ссылки подставляются в значения полей
+func (c *checker) checkObjectRefs(path string, input json.RawMessage, keys map[string]struct{}) {
+	if len(input) == 0 {
+		return
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(input, &fields); err != nil {
+		// Ошибка формата уже отмечена в checkState
+		return
+	}
+	for key, value := range fields {
+		c.checkRef(path+"."+key, value, keys)
+	}
+}
+
+func (c *checker) checkConditionRefs(path string, cond engine.Condition, keys map[string]struct{}) {
+	for i, sub := range cond.And {
+		c.checkConditionRefs(fmt.Sprintf("%s.and[%d]", path, i), sub, keys)
+	}
+	for i, sub := range cond.Or {
+		c.checkConditionRefs(fmt.Sprintf("%s.or[%d]", path, i), sub, keys)
+	}
+	if cond.Not != nil {
+		c.checkConditionRefs(path+".not", *cond.Not, keys)
+	}
+
+	// exists как раз проверяет наличие ключа, поэтому его переменная может отсутствовать
+	if cond.Variable != "" && cond.Operator != "exists" {
+		c.checkRef(path+".variable", "$."+strings.TrimPrefix(cond.Variable, "$."), keys)
+	}
+	if len(cond.Value) > 0 {
+		var value interface{}
+		if err := json.Unmarshal(cond.Value, &value); err == nil {
+			c.checkRef(path+".value", value, keys)
+		}
+	}
+}
+
+// checkRef проверяет ссылки в значении: строку "$.a[0].b" целиком и обертки {"$expr": ...}, {"$template": ...}
+// на любой глубине. Вложенные строки - литералы и ничего не требуют.
+func (c *checker) checkRef(path string, value interface{}, keys map[string]struct{}) {
+	refs, err := engine.ValueRefs(value)
+	if err != nil {
+		c.add(path, "invalid expression: %v", err)
+		return
+	}
+	for _, key := range refs {
+		if _, ok := keys[key]; !ok {
+			c.add(path, "value uses %q which no earlier state produces (available: %s)", key, joinKeys(keys))
+		}
+	}
+}
+
+func withKeys(keys map[string]struct{}, extra ...string) map[string]struct{} {
+	result := make(map[string]struct{}, len(keys)+len(extra))
+	for k := range keys {
+		result[k] = struct{}{}
+	}
+	for _, k := range extra {
+		result[k] = struct{}{}
+	}
+	return result
+}
@@ -0,0 +1,621 @@
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/xeipuuv/gojsonschema"
+
+	"github.com/aimustaev/service-workflow/internal/engine"
+)
+
+// Error describes a single problem found in a workflow definition
+type Error struct {
+	Path    string `json:"path"` // JSON path внутри определения, например "$.states[3].activityName"
+	Message string `json:"message"`
+}
+
+func (e Error) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// DefinitionSource returns active definitions of other configs referenced by subworkflow states
+type DefinitionSource interface {
+	GetWorkflowDefinition(name string) (engine.WorkflowDefinition, error)
+}
+
+// Validator statically checks workflow definitions before they are stored
+type Validator struct {
+	activities  map[string]struct{}
+	definitions DefinitionSource
+	calendars   engine.CalendarSource
+}
+
+// NewValidator creates a validator that accepts the given activity names.
+// definitions may be nil, then subworkflow targets and cycles through other configs are not checked.
+// calendars may be nil, then calendars referenced by business-time states are not checked.
+func NewValidator(activityNames []string, definitions DefinitionSource, calendars engine.CalendarSource) *Validator {
+	activities := make(map[string]struct{}, len(activityNames))
+	for _, name := range activityNames {
+		activities[name] = struct{}{}
+	}
+	return &Validator{activities: activities, definitions: definitions, calendars: calendars}
+}
+
+// ValidateContent parses raw config content and validates the resulting definition
+// as the content of the config stored under name
+func (v *Validator) ValidateContent(name string, content []byte) []Error {
+	var def engine.WorkflowDefinition
+	if err := json.Unmarshal(content, &def); err != nil {
+		return []Error{{Path: "$", Message: fmt.Sprintf("invalid workflow definition: %v", err)}}
+	}
+	return v.Validate(name, def)
+}
+
+// Validate checks a workflow definition and returns all found problems.
+// name is the config name the definition is stored under: subworkflow states start configs
+// by that name, so cycles are checked against it. An empty name falls back to def.Name.
+func (v *Validator) Validate(name string, def engine.WorkflowDefinition) []Error {
+	c := &checker{validator: v}
+
+	c.checkSchema("$.inputSchema", def.InputSchema)
+	c.checkTimeouts("$.timeouts", def.Timeouts)
+	c.checkRetry("$.retry", def.Retry)
+	if policy := def.ContinueAsNew; policy != nil {
+		if policy.MaxHistoryEvents < 0 || policy.MaxHistoryBytes < 0 {
+			c.add("$.continueAsNew", "thresholds must not be negative")
+		}
+		if policy.MaxHistoryEvents == 0 && policy.MaxHistoryBytes == 0 && !policy.WhenSuggested {
+			c.add("$.continueAsNew", "at least one threshold is required")
+		}
+	}
+
+	if def.Schedule != nil {
+		c.checkSchedule("$.schedule", *def.Schedule, def.InputSchema)
+	}
+
+	root := newScope("$.states", def.States, def.StartAt, "$.startAt")
+	c.checkScope(root, map[string]struct{}{"input": {}})
+	if name == "" {
+		name = def.Name
+	}
+	c.checkSubworkflowCycles(name)
+
+	return c.errors
+}
+
+type checker struct {
+	validator    *Validator
+	errors       []Error
+	subworkflows []subworkflowRef // subworkflow-состояния, найденные при обходе
+}
+
+// scope - список состояний со своим пространством имен: определение целиком или ветка parallel
+type scope struct {
+	path        string // JSON path списка состояний, например "$.states"
+	states      []engine.StateDefinition
+	startAt     string
+	startAtPath string
+	names       map[string]int
+}
+
+func newScope(path string, states []engine.StateDefinition, startAt, startAtPath string) *scope {
+	return &scope{
+		path:        path,
+		states:      states,
+		startAt:     startAt,
+		startAtPath: startAtPath,
+		names:       make(map[string]int),
+	}
+}
+
+func (s *scope) statePath(i int) string {
+	return fmt.Sprintf("%s[%d]", s.path, i)
+}
+
+func (s *scope) stateExists(name string) bool {
+	_, ok := s.names[name]
+	return ok
+}
+
+func (c *checker) add(path, format string, args ...interface{}) {
+	c.errors = append(c.errors, Error{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// checkScope проверяет список состояний; keys - ключи state, доступные на входе,
+// nil означает, что список недостижим и ссылки в нем не проверяются
+func (c *checker) checkScope(s *scope, keys map[string]struct{}) {
+	if len(s.states) == 0 {
+		c.add(s.path, "at least one state is required")
+		return
+	}
+
+	c.checkNames(s)
+	if s.startAt != "" && !s.stateExists(s.startAt) {
+		c.add(s.startAtPath, "unknown state %q", s.startAt)
+	}
+
+	var available map[int]map[string]struct{}
+	if keys != nil {
+		available = c.availableKeys(s, keys)
+	}
+
+	for i, state := range s.states {
+		path := s.statePath(i)
+		c.checkState(s, path, state, true)
+
+		stateKeys, reachable := available[i]
+		if reachable {
+			c.checkStateRefs(path, state, stateKeys)
+		}
+
+		for j, branch := range state.Branches {
+			branchPath := fmt.Sprintf("%s.branches[%d]", path, j)
+			c.checkScope(newScope(branchPath+".states", branch.States, branch.StartAt, branchPath+".startAt"), stateKeys)
+		}
+
+		if state.Iterator != nil {
+			iterPath := path + ".iterator"
+			var iterKeys map[string]struct{}
+			if reachable {
+				iterKeys = withKeys(stateKeys, loopKeys(state)...)
+			}
+			c.checkScope(newScope(iterPath+".states", state.Iterator.States, state.Iterator.StartAt, iterPath+".startAt"), iterKeys)
+		}
+	}
+}
+
+// checkNames проверяет, что имена состояний заданы и уникальны
+func (c *checker) checkNames(s *scope) {
+	for i, state := range s.states {
+		if state.Name == "" {
+			c.add(s.statePath(i)+".name", "state name is required")
+			continue
+		}
+		if first, ok := s.names[state.Name]; ok {
+			c.add(s.statePath(i)+".name", "duplicate state name %q, already used by %s", state.Name, s.statePath(first))
+			continue
+		}
+		s.names[state.Name] = i
+	}
+}
+
+// checkState проверяет одно состояние; topLevel=false для вложенных actions и compensate
+func (c *checker) checkState(s *scope, path string, state engine.StateDefinition, topLevel bool) {
+	switch state.Type {
+	case "activity":
+		c.checkActivity(path, state)
+
+	case "signal":
+		if state.SignalName == "" {
+			c.add(path+".signalName", "signal state requires signalName")
+		}
+		c.checkPayloadType(path+".payloadType", state.PayloadType)
+		c.checkActions(s, path, state.Actions)
+
+	case "timer":
+		switch {
+		case state.TimerDuration != "" && state.BusinessDuration != "":
+			c.add(path+".businessDuration", "timer state accepts either timerDuration or businessDuration")
+		case state.BusinessDuration != "":
+			c.checkBusinessTime(path, "businessDuration", state.BusinessDuration, state.Calendar)
+		case state.TimerDuration == "":
+			c.add(path+".timerDuration", "timer state requires timerDuration or businessDuration")
+		default:
+			c.checkDuration(path+".timerDuration", state.TimerDuration)
+		}
+		c.checkActions(s, path, state.Actions)
+
+	case "choice":
+		if len(state.Choices) == 0 && state.Default == "" {
+			c.add(path+".choices", "choice state requires choices or default")
+		}
+		for i, rule := range state.Choices {
+			rulePath := fmt.Sprintf("%s.choices[%d]", path, i)
+			c.checkCondition(rulePath+".condition", rule.Condition)
+			c.checkTransition(s, rulePath+".next", rule.Next, true)
+		}
+		c.checkTransition(s, path+".default", state.Default, false)
+
+	case "waitForSignal":
+		if len(state.Signals) == 0 {
+			c.add(path+".signals", "waitForSignal state requires at least one signal")
+		}
+		seen := make(map[string]struct{}, len(state.Signals))
+		for i, signal := range state.Signals {
+			signalPath := fmt.Sprintf("%s.signals[%d]", path, i)
+			if signal.Name == "" {
+				c.add(signalPath+".name", "signal name is required")
+			} else if _, dup := seen[signal.Name]; dup {
+				c.add(signalPath+".name", "duplicate signal %q", signal.Name)
+			}
+			seen[signal.Name] = struct{}{}
+			c.checkPayloadType(signalPath+".payloadType", signal.PayloadType)
+			c.checkTransition(s, signalPath+".next", signal.Next, false)
+		}
+		c.checkWaitTimeout(s, path, state)
+
+	case "userTask":
+		if len(state.Input) == 0 || state.Input[0] != '{' {
+			c.add(path+".input", "userTask input must be an object with title")
+		} else {
+			var fields map[string]json.RawMessage
+			if err := json.Unmarshal(state.Input, &fields); err == nil {
+				if _, ok := fields["title"]; !ok {
+					c.add(path+".input.title", "userTask requires title")
+				}
+			}
+		}
+		c.checkSchema(path+".formSchema", state.FormSchema)
+		c.checkWaitTimeout(s, path, state)
+
+	case "select":
+		c.checkSelect(s, path, state)
+
+	case "parallel":
+		c.checkParallel(path, state)
+
+	case "subworkflow":
+		if state.Workflow == "" {
+			c.add(path+".workflow", "subworkflow state requires workflow")
+		} else {
+			c.subworkflows = append(c.subworkflows, subworkflowRef{path: path + ".workflow", name: state.Workflow})
+		}
+		switch state.Mode {
+		case "", "wait":
+		case "async":
+			if len(state.OutputMapping) > 0 {
+				c.add(path+".outputMapping", "outputMapping is not available in async mode")
+			}
+		default:
+			c.add(path+".mode", "unknown mode %q", state.Mode)
+		}
+		switch state.ParentClosePolicy {
+		case "", "terminate", "abandon", "requestCancel":
+		default:
+			c.add(path+".parentClosePolicy", "unknown parentClosePolicy %q", state.ParentClosePolicy)
+		}
+		if len(state.Input) > 0 && state.Input[0] != '{' {
+			c.add(path+".input", "subworkflow input must be an object")
+		}
+		for key, ref := range state.OutputMapping {
+			if ref != "$" && !strings.HasPrefix(ref, "$.") {
+				c.add(path+".outputMapping."+key, "output mapping must be a $ reference")
+			}
+		}
+
+	case "set":
+		if len(state.Values) == 0 {
+			c.add(path+".values", "set state requires values")
+		}
+
+	case "foreach":
+		if !strings.HasPrefix(state.ItemsPath, "$.") {
+			c.add(path+".itemsPath", "foreach state requires itemsPath as a $. reference")
+		}
+		if state.MaxConcurrency < 0 {
+			c.add(path+".maxConcurrency", "maxConcurrency must not be negative")
+		}
+		c.checkIterator(path, state.Iterator)
+
+	case "while":
+		if state.Condition == nil {
+			c.add(path+".condition", "while state requires condition")
+		} else {
+			c.checkCondition(path+".condition", *state.Condition)
+		}
+		// Без maxIterations движок ограничивает цикл defaultMaxIterations итерациями
+		if state.MaxIterations != nil && *state.MaxIterations <= 0 {
+			c.add(path+".maxIterations", "maxIterations must be positive")
+		}
+		c.checkIterator(path, state.Iterator)
+
+	case "":
+		c.add(path+".type", "state type is required")
+
+	default:
+		c.add(path+".type", "unknown state type %q", state.Type)
+	}
+
+	if state.Calendar != "" && state.BusinessDuration == "" && state.BusinessTimeout == "" {
+		c.add(path+".calendar", "calendar is used only with businessDuration or businessTimeout")
+	}
+
+	c.checkSchema(path+".outputSchema", state.OutputSchema)
+	c.checkTimeouts(path+".timeouts", state.Timeouts)
+	c.checkRetry(path+".retry", state.Retry)
+
+	if !topLevel {
+		return
+	}
+
+	c.checkTransition(s, path+".next", state.Next, false)
+	for i, rule := range state.Catch {
+		c.checkTransition(s, fmt.Sprintf("%s.catch[%d].next", path, i), rule.Next, true)
+	}
+	if state.Compensate != nil {
+		if state.Compensate.Type != "activity" {
+			c.add(path+".compensate.type", "compensate must be an activity")
+		} else {
+			c.checkActivity(path+".compensate", *state.Compensate)
+		}
+	}
+}
+
+func (c *checker) checkActivity(path string, state engine.StateDefinition) {
+	if state.ActivityName == "" {
+		c.add(path+".activityName", "activity state requires activityName")
+		return
+	}
+	if _, ok := c.validator.activities[state.ActivityName]; !ok {
+		c.add(path+".activityName", "unknown activity %q", state.ActivityName)
+	}
+}
+
+// checkParallel проверяет ветки и условие join; сами состояния веток проверяются в checkScope
+func (c *checker) checkParallel(path string, state engine.StateDefinition) {
+	if len(state.Branches) == 0 {
+		c.add(path+".branches", "parallel state requires at least one branch")
+	}
+
+	seen := make(map[string]struct{}, len(state.Branches))
+	for i, branch := range state.Branches {
+		branchPath := fmt.Sprintf("%s.branches[%d]", path, i)
+		if branch.Name == "" {
+			c.add(branchPath+".name", "branch name is required")
+		} else if _, dup := seen[branch.Name]; dup {
+			c.add(branchPath+".name", "duplicate branch name %q", branch.Name)
+		}
+		seen[branch.Name] = struct{}{}
+	}
+
+	switch state.Join {
+	case "", "all", "any":
+	case "first":
+		if state.JoinCount <= 0 || state.JoinCount > len(state.Branches) {
+			c.add(path+".joinCount", "joinCount must be between 1 and %d", len(state.Branches))
+		}
+	default:
+		c.add(path+".join", "unknown join mode %q", state.Join)
+	}
+}
+
+// checkIterator проверяет наличие тела цикла; сами состояния тела проверяются в checkScope
+func (c *checker) checkIterator(path string, it *engine.Iterator) {
+	if it == nil {
+		c.add(path+".iterator", "loop state requires iterator")
+		return
+	}
+	if it.Result != "" && !strings.HasPrefix(it.Result, "$.") {
+		c.add(path+".iterator.result", "result must be a $. reference")
+	}
+}
+
+func (c *checker) checkPayloadType(path, payloadType string) {
+	if payloadType == "" {
+		return
+	}
+	for _, known := range engine.PayloadTypes() {
+		if known == payloadType {
+			return
+		}
+	}
+	c.add(path, "unknown payload type %q (known: %s)", payloadType, strings.Join(engine.PayloadTypes(), ", "))
+}
+
+// checkSelect проверяет события select: у каждого события есть имя и ровно один источник - сигнал или таймер
+func (c *checker) checkSelect(s *scope, path string, state engine.StateDefinition) {
+	if len(state.Events) < 2 {
+		c.add(path+".events", "select state requires at least two events")
+	}
+
+	names := make(map[string]struct{}, len(state.Events))
+	signals := make(map[string]struct{}, len(state.Events))
+	for i, event := range state.Events {
+		eventPath := fmt.Sprintf("%s.events[%d]", path, i)
+		if event.Name == "" {
+			c.add(eventPath+".name", "event name is required")
+		} else if _, dup := names[event.Name]; dup {
+			c.add(eventPath+".name", "duplicate event %q", event.Name)
+		}
+		names[event.Name] = struct{}{}
+
+		sources := 0
+		for _, value := range []string{event.Signal, event.Timer, event.BusinessDuration} {
+			if value != "" {
+				sources++
+			}
+		}
+		if sources != 1 {
+			c.add(eventPath, "event requires exactly one of signal, timer or businessDuration")
+		}
+
+		if event.Signal != "" {
+			if _, dup := signals[event.Signal]; dup {
+				c.add(eventPath+".signal", "signal %q is already used by another event", event.Signal)
+			}
+			signals[event.Signal] = struct{}{}
+			c.checkPayloadType(eventPath+".payloadType", event.PayloadType)
+		} else {
+			if event.PayloadType != "" {
+				c.add(eventPath+".payloadType", "payloadType is used only with signal")
+			}
+			if event.Output != "" {
+				c.add(eventPath+".output", "output is used only with signal")
+			}
+		}
+
+		c.checkDuration(eventPath+".timer", event.Timer)
+		if event.BusinessDuration != "" {
+			c.checkBusinessTime(eventPath, "businessDuration", event.BusinessDuration, event.Calendar)
+		} else if event.Calendar != "" {
+			c.add(eventPath+".calendar", "calendar is used only with businessDuration")
+		}
+
+		c.checkTransition(s, eventPath+".next", event.Next, false)
+		c.checkActions(s, eventPath, event.Actions)
+	}
+}
+
+// checkWaitTimeout проверяет срок ожидания waitForSignal и userTask и переход по нему
+func (c *checker) checkWaitTimeout(s *scope, path string, state engine.StateDefinition) {
+	c.checkDuration(path+".timeout", state.Timeout)
+	if state.BusinessTimeout != "" {
+		if state.Timeout != "" {
+			c.add(path+".businessTimeout", "%s state accepts either timeout or businessTimeout", state.Type)
+		}
+		c.checkBusinessTime(path, "businessTimeout", state.BusinessTimeout, state.Calendar)
+	}
+	if state.TimeoutNext != "" && state.Timeout == "" && state.BusinessTimeout == "" {
+		c.add(path+".timeoutNext", "timeoutNext requires timeout")
+	}
+	c.checkTransition(s, path+".timeoutNext", state.TimeoutNext, false)
+}
+
+func (c *checker) checkActions(s *scope, path string, actions []engine.StateDefinition) {
+	for i, action := range actions {
+		c.checkState(s, fmt.Sprintf("%s.actions[%d]", path, i), action, false)
+	}
+}
+
+func (c *checker) checkTransition(s *scope, path, target string, required bool) {
+	if target == "" {
+		if required {
+			c.add(path, "transition target is required")
+		}
+		return
+	}
+	if !s.stateExists(target) {
+		c.add(path, "unknown state %q", target)
+	}
+}
+
+var conditionOperators = map[string]struct{}{
+	"": {}, "eq": {}, "ne": {}, "gt": {}, "gte": {}, "lt": {}, "lte": {}, "exists": {}, "in": {},
+}
+
+func (c *checker) checkCondition(path string, cond engine.Condition) {
+	switch {
+	case len(cond.And) > 0:
+		for i, sub := range cond.And {
+			c.checkCondition(fmt.Sprintf("%s.and[%d]", path, i), sub)
+		}
+	case len(cond.Or) > 0:
+		for i, sub := range cond.Or {
+			c.checkCondition(fmt.Sprintf("%s.or[%d]", path, i), sub)
+		}
+	case cond.Not != nil:
+		c.checkCondition(path+".not", *cond.Not)
+	default:
+		if cond.Variable == "" {
+			c.add(path+".variable", "condition variable is required")
+		}
+		if _, ok := conditionOperators[cond.Operator]; !ok {
+			c.add(path+".operator", "unknown operator %q", cond.Operator)
+		}
+	}
+}
+
+func (c *checker) checkSchema(path string, schema json.RawMessage) {
+	if _, err := engine.CompileSchema(schema); err != nil {
+		c.add(path, "invalid JSON schema: %v", err)
+	}
+}
+
+// checkSchedule проверяет расписание; cron разбирает сервер Temporal, здесь проверяется только его форма
+func (c *checker) checkSchedule(path string, schedule engine.Schedule, inputSchema json.RawMessage) {
+	fields := strings.Fields(schedule.Cron)
+	switch {
+	case len(fields) == 0:
+		c.add(path+".cron", "schedule requires cron")
+	case strings.HasPrefix(fields[0], "@"):
+	case len(fields) < 5 || len(fields) > 7:
+		c.add(path+".cron", "invalid cron expression %q, expected 5 to 7 fields", schedule.Cron)
+	}
+
+	if schedule.TimeZone != "" {
+		if _, err := time.LoadLocation(schedule.TimeZone); err != nil {
+			c.add(path+".timeZone", "invalid timeZone %q", schedule.TimeZone)
+		}
+	}
+	c.checkDuration(path+".jitter", schedule.Jitter)
+	if _, err := schedule.OverlapPolicy(); err != nil {
+		c.add(path+".overlap", "%v", err)
+	}
+
+	// Вход запусков по расписанию проверяется по inputSchema заранее, иначе каждый запуск упадет
+	input, err := schedule.WorkflowInput()
+	if err != nil {
+		c.add(path+".input", "%v", err)
+		return
+	}
+	compiled, err := engine.CompileSchema(inputSchema)
+	if err != nil || compiled == nil {
+		return
+	}
+	result, err := compiled.Validate(gojsonschema.NewGoLoader(input))
+	if err != nil {
+		c.add(path+".input", "failed to validate input: %v", err)
+		return
+	}
+	for _, e := range result.Errors() {
+		c.add(path+".input", "input does not match inputSchema: %s", e.String())
+	}
+}
+
+func (c *checker) checkTimeouts(path string, timeouts engine.Timeouts) {
+	c.checkDuration(path+".startToClose", timeouts.StartToClose)
+	c.checkDuration(path+".scheduleToClose", timeouts.ScheduleToClose)
+	c.checkDuration(path+".heartbeat", timeouts.Heartbeat)
+}
+
+func (c *checker) checkRetry(path string, retry *engine.RetryPolicy) {
+	if retry == nil {
+		return
+	}
+	c.checkDuration(path+".initialInterval", retry.InitialInterval)
+	c.checkDuration(path+".maximumInterval", retry.MaximumInterval)
+	if retry.BackoffCoefficient != 0 && retry.BackoffCoefficient < 1 {
+		c.add(path+".backoffCoefficient", "backoff coefficient must be at least 1")
+	}
+	if retry.MaximumAttempts < 0 {
+		c.add(path+".maximumAttempts", "maximum attempts must not be negative")
+	}
+}
+
+func (c *checker) checkDuration(path, value string) {
+	if value == "" {
+		return
+	}
+	if _, err := time.ParseDuration(value); err != nil {
+		c.add(path, "invalid duration %q", value)
+	}
+}
+
+// checkBusinessTime проверяет длительность в рабочем времени и календарь, по которому она считается
+func (c *checker) checkBusinessTime(path, field, value, calendar string) {
+	c.checkDuration(path+"."+field, value)
+	if calendar == "" {
+		c.add(path+".calendar", "%s requires calendar", field)
+		return
+	}
+	if c.validator.calendars == nil {
+		return
+	}
+	if _, err := c.validator.calendars.GetCalendar(calendar); err != nil {
+		c.add(path+".calendar", "calendar %q: %v", calendar, err)
+	}
+}
+
+// joinKeys возвращает отсортированный список ключей для сообщений об ошибках
+func joinKeys(keys map[string]struct{}) string {
+	list := make([]string, 0, len(keys))
+	for k := range keys {
+		list = append(list, k)
+	}
+	sort.Strings(list)
+	return strings.Join(list, ", ")
+}
@@ -0,0 +1,104 @@
+// Package valuepath resolves dotted paths like "tickets[0].Id" in decoded JSON values and protobuf messages.
+// It is shared by the workflow engine and activities, so both read paths the same way.
+package valuepath
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Lookup returns the value at a dotted path, e.g. "input.Message" or "body.items[-1].id".
+// List indices may be negative, then they count from the end.
+func Lookup(value interface{}, path string) (interface{}, bool) {
+	current := value
+
+	for _, k := range strings.Split(path, ".") {
+		// Отделяем индексы: tickets[0][1] -> tickets, 0, 1
+		name := k
+		var indices []string
+		if i := strings.IndexByte(k, '['); i >= 0 {
+			name = k[:i]
+			for _, idx := range strings.Split(k[i+1:], "[") {
+				indices = append(indices, strings.TrimSuffix(idx, "]"))
+			}
+		}
+
+		if name != "" {
+			val, ok := Member(current, name)
+			if !ok {
+				return nil, false
+			}
+			current = val
+		}
+
+		for _, idx := range indices {
+			i, err := strconv.Atoi(idx)
+			if err != nil {
+				return nil, false
+			}
+			val, ok := Index(current, i)
+			if !ok {
+				return nil, false
+			}
+			current = val
+		}
+	}
+
+	return current, true
+}
+
+// Member returns a field of a map or a struct, including protobuf messages
+func Member(current interface{}, name string) (interface{}, bool) {
+	// Пытаемся обработать как map[string]interface{}
+	if m, ok := current.(map[string]interface{}); ok {
+		val, exists := m[name]
+		return val, exists
+	}
+
+	// Пытаемся обработать как protobuf-сообщение (через рефлексию)
+	val := reflect.ValueOf(current)
+	if val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return nil, false
+		}
+		val = val.Elem() // Разыменовываем указатель (*proto.Ticket → proto.Ticket)
+	}
+
+	switch val.Kind() {
+	case reflect.Struct:
+		// Ищем поле в protobuf-структуре
+		field := val.FieldByName(name)
+		if !field.IsValid() || !field.CanInterface() {
+			return nil, false
+		}
+		return field.Interface(), true
+
+	case reflect.Map:
+		if val.Type().Key().Kind() != reflect.String {
+			return nil, false
+		}
+		field := val.MapIndex(reflect.ValueOf(name).Convert(val.Type().Key()))
+		if !field.IsValid() {
+			return nil, false
+		}
+		return field.Interface(), true
+	}
+
+	return nil, false // Не мапа и не структура
+}
+
+// Index returns an element of a list; a negative index counts from the end
+func Index(current interface{}, i int) (interface{}, bool) {
+	val := reflect.ValueOf(current)
+	if val.Kind() != reflect.Slice && val.Kind() != reflect.Array {
+		return nil, false
+	}
+	if i < 0 {
+		i += val.Len()
+	}
+	if i < 0 || i >= val.Len() {
+		return nil, false
+	}
+	return val.Index(i).Interface(), true
+}
@@ -1,52 +1,244 @@
 package workflow
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"github.com/aimustaev/service-workflow/internal/manager_workflow"
+	"time"
+
+	"github.com/google/uuid"
 
 	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/workflow"
 
 	act "github.com/aimustaev/service-workflow/internal/activity"
 	"github.com/aimustaev/service-workflow/internal/engine"
+	"github.com/aimustaev/service-workflow/internal/manager_workflow"
 )
 
+// DefinitionSource loads the workflow definitions executed by DynamicWorkflow.
+// It is implemented by manager_workflow.ConfigManager.
+type DefinitionSource interface {
+	GetWorkflowDefinition(name string) (engine.WorkflowDefinition, error)
+	GetActiveConfigRef(name string) (manager_workflow.ConfigRef, error)
+	GetWorkflowDefinitionVersion(id uuid.UUID, version string) (engine.WorkflowDefinition, error)
+}
+
 type DynamicWorkflow struct {
 	activity      *act.Activity
 	engine        *engine.WorkflowEngine
-	configManager *manager_workflow.ConfigManager
+	configManager DefinitionSource
 }
 
-func NewDynamicWorkflow(activity *act.Activity, temporalClient client.Client, configManager *manager_workflow.ConfigManager) *DynamicWorkflow {
-	// Создаем движок с маппингом activity
-	activitiesMap := map[string]interface{}{
-		"CreateTicketActivity":       activity.CreateTicketActivity,
-		"ProcessMessageActivity":     activity.ProcessMessageActivity,
-		"WaitActivity":               activity.WaitActivity,
-		"ClassifierAcitivity":        activity.ClassifierAcitivity,
-		"GetOrCreateTicketActivity":  activity.GetOrCreateTicketActivity,
-		"GetTicketByUserActivity":    activity.GetTicketByUserActivity,
-		"AddMassageToTicketActivity": activity.AddMassageToTicketActivity,
-		"SolveTicketAcitivity":       activity.SolveTicketAcitivity,
-	}
-
+// NewDynamicWorkflow creates the workflow. calendars may be nil when histories are only replayed:
+// calendars of business-time timers are then taken from the history.
+func NewDynamicWorkflow(activity *act.Activity, temporalClient client.Client, configManager DefinitionSource, registry *act.Registry, calendars engine.CalendarSource) *DynamicWorkflow {
 	return &DynamicWorkflow{
 		activity:      activity,
-		engine:        engine.NewEngine(temporalClient, activitiesMap),
+		engine:        engine.NewEngine(temporalClient, registry).WithCalendars(calendars),
 		configManager: configManager,
 	}
 }
 
-// DynamicWorkflow - универсальный обработчик для всех workflow
-func (w *DynamicWorkflow) Execute(ctx workflow.Context, input map[string]interface{}) (interface{}, error) {
-	// Получаем имя workflow из контекста
-	workflowName := workflow.GetInfo(ctx).WorkflowType.Name
+// Ключи memo с закрепленной за выполнением версией конфигурации
+const (
+	ConfigIDMemoKey      = "configId"
+	ConfigVersionMemoKey = "configVersion"
+)
+
+// configVersionChangeID - маркер версии кода, начиная с которой workflow закрепляется за версией конфигурации.
+// Версия 1 определяла активную версию через SideEffect, версия 2 - через local activity с повторами.
+const configVersionChangeID = "pin-config-version"
+
+// configNotFoundErrorType - тип ошибки local activity, когда у конфигурации нет активной версии
+const configNotFoundErrorType = "ConfigNotFound"
+
+// configRefOptions - опции local activity, определяющей активную версию конфигурации.
+// Повторы не ограничены: временная ошибка БД не должна завершать выполнение.
+var configRefOptions = workflow.LocalActivityOptions{
+	StartToCloseTimeout: 10 * time.Second,
+	RetryPolicy: &temporal.RetryPolicy{
+		InitialInterval:    time.Second,
+		BackoffCoefficient: 2,
+		MaximumInterval:    time.Minute,
+	},
+}
+
+// Resume - данные, с которыми DynamicWorkflow продолжается после continue-as-new
+type Resume struct {
+	ConfigName   string                     `json:"configName"`
+	Config       manager_workflow.ConfigRef `json:"config"` // Пустая ссылка - выполнение без закрепленной версии
+	Continuation engine.Continuation        `json:"continuation"`
+}
+
+// DynamicWorkflow - универсальный обработчик для всех workflow.
+// resume передается только при continue-as-new, новые запуски получают nil.
+func (w *DynamicWorkflow) Execute(ctx workflow.Context, input map[string]interface{}, resume *Resume) (interface{}, error) {
+	if resume != nil {
+		return w.resume(ctx, input, resume)
+	}
+
+	workflowName, err := configName(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	// Получаем актуальное определение workflow из менеджера конфигураций
-	def, err := w.configManager.GetWorkflowDefinition(workflowName)
+	def, ref, err := w.resolveDefinition(ctx, workflowName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get workflow definition: %w", err)
 	}
 
-	return w.engine.ExecuteWorkflow(ctx, def, input)
+	result, err := w.engine.ExecuteWorkflow(ctx, def, input)
+	return w.complete(ctx, input, workflowName, ref, result, err)
+}
+
+// resume продолжает выполнение с той же версией конфигурации, что и предыдущий запуск
+func (w *DynamicWorkflow) resume(ctx workflow.Context, input map[string]interface{}, resume *Resume) (interface{}, error) {
+	var (
+		def engine.WorkflowDefinition
+		err error
+	)
+	if resume.Config.ID == uuid.Nil {
+		def, err = w.configManager.GetWorkflowDefinition(resume.ConfigName)
+	} else {
+		def, err = w.configManager.GetWorkflowDefinitionVersion(resume.Config.ID, resume.Config.Version)
+	}
+	if err := failTaskOnTransient(err); err != nil {
+		return nil, fmt.Errorf("failed to get workflow definition: %w", err)
+	}
+
+	memo := map[string]interface{}{engine.ConfigNameMemoKey: resume.ConfigName}
+	if resume.Config.ID != uuid.Nil {
+		memo[ConfigIDMemoKey] = resume.Config.ID.String()
+		memo[ConfigVersionMemoKey] = resume.Config.Version
+	}
+	if err := workflow.UpsertMemo(ctx, memo); err != nil {
+		return nil, err
+	}
+
+	result, err := w.engine.ContinueWorkflow(ctx, def, input, resume.Continuation)
+	return w.complete(ctx, input, resume.ConfigName, resume.Config, result, err)
+}
+
+// complete превращает запрос движка на continue-as-new в новый запуск этого же workflow
+func (w *DynamicWorkflow) complete(ctx workflow.Context, input map[string]interface{}, workflowName string, ref manager_workflow.ConfigRef, result interface{}, err error) (interface{}, error) {
+	var continueErr *engine.ContinueAsNewError
+	if !errors.As(err, &continueErr) {
+		return result, err
+	}
+
+	return nil, workflow.NewContinueAsNewError(ctx, workflow.GetInfo(ctx).WorkflowType.Name, input, &Resume{
+		ConfigName:   workflowName,
+		Config:       ref,
+		Continuation: continueErr.Continuation,
+	})
+}
+
+// configName возвращает имя конфигурации: дочерние workflow (subworkflow) получают его в memo,
+// остальные выполняют конфигурацию с именем своего типа
+func configName(ctx workflow.Context) (string, error) {
+	info := workflow.GetInfo(ctx)
+
+	if info.Memo != nil {
+		if payload, ok := info.Memo.Fields[engine.ConfigNameMemoKey]; ok {
+			var name string
+			if err := converter.GetDefaultDataConverter().FromPayload(payload, &name); err != nil {
+				return "", fmt.Errorf("failed to decode %s memo: %w", engine.ConfigNameMemoKey, err)
+			}
+			return name, nil
+		}
+	}
+
+	return info.WorkflowType.Name, nil
+}
+
+// resolveDefinition определяет версию конфигурации один раз при старте и сохраняет её в истории,
+// поэтому при replay всегда загружается та же версия, даже если активной стала другая.
+func (w *DynamicWorkflow) resolveDefinition(ctx workflow.Context, workflowName string) (engine.WorkflowDefinition, manager_workflow.ConfigRef, error) {
+	// Выполнения, начатые до закрепления версий, продолжают получать актуальную конфигурацию
+	version := workflow.GetVersion(ctx, configVersionChangeID, workflow.DefaultVersion, 2)
+	if version == workflow.DefaultVersion {
+		def, err := w.configManager.GetWorkflowDefinition(workflowName)
+		return def, manager_workflow.ConfigRef{}, failTaskOnTransient(err)
+	}
+
+	var (
+		ref manager_workflow.ConfigRef
+		err error
+	)
+	if version == 1 {
+		ref, err = w.sideEffectConfigRef(ctx, workflowName)
+	} else {
+		ref, err = w.activeConfigRef(ctx, workflowName)
+	}
+	if err != nil {
+		return engine.WorkflowDefinition{}, ref, err
+	}
+
+	// Сохраняем закрепленную версию в memo, чтобы её было видно в Temporal UI и API
+	err = workflow.UpsertMemo(ctx, map[string]interface{}{
+		ConfigIDMemoKey:      ref.ID.String(),
+		ConfigVersionMemoKey: ref.Version,
+	})
+	if err != nil {
+		return engine.WorkflowDefinition{}, ref, err
+	}
+
+	def, err := w.configManager.GetWorkflowDefinitionVersion(ref.ID, ref.Version)
+	return def, ref, failTaskOnTransient(err)
+}
+
+// failTaskOnTransient прерывает задачу workflow, если определение не загрузилось из-за сбоя:
+// Temporal повторит задачу, а выполнение не завершится ошибкой. Отсутствие конфигурации остается ошибкой workflow.
+func failTaskOnTransient(err error) error {
+	if err != nil && !errors.Is(err, manager_workflow.ErrConfigNotFound) {
+		panic(fmt.Sprintf("failed to load workflow definition: %v", err))
+	}
+	return err
+}
+
+// activeConfigRef определяет активную версию конфигурации local activity. Временные ошибки повторяются,
+// поэтому в историю попадает только найденная версия или ее отсутствие.
+func (w *DynamicWorkflow) activeConfigRef(ctx workflow.Context, workflowName string) (manager_workflow.ConfigRef, error) {
+	var ref manager_workflow.ConfigRef
+	ctx = workflow.WithLocalActivityOptions(ctx, configRefOptions)
+	err := workflow.ExecuteLocalActivity(ctx, w.resolveConfigRef, workflowName).Get(ctx, &ref)
+
+	var appErr *temporal.ApplicationError
+	if errors.As(err, &appErr) && appErr.Type() == configNotFoundErrorType {
+		return ref, manager_workflow.ErrConfigNotFound
+	}
+	return ref, err
+}
+
+// resolveConfigRef - local activity: отсутствие активной версии не повторяется, остальные ошибки - повторяются
+func (w *DynamicWorkflow) resolveConfigRef(ctx context.Context, workflowName string) (manager_workflow.ConfigRef, error) {
+	ref, err := w.configManager.GetActiveConfigRef(workflowName)
+	if errors.Is(err, manager_workflow.ErrConfigNotFound) {
+		return ref, temporal.NewNonRetryableApplicationError(err.Error(), configNotFoundErrorType, err)
+	}
+	return ref, err
+}
+
+// sideEffectConfigRef воспроизводит определение версии через SideEffect для выполнений, начатых
+// до перехода на local activity: результат берется из истории
+func (w *DynamicWorkflow) sideEffectConfigRef(ctx workflow.Context, workflowName string) (manager_workflow.ConfigRef, error) {
+	var ref manager_workflow.ConfigRef
+	err := workflow.SideEffect(ctx, func(ctx workflow.Context) interface{} {
+		ref, err := w.configManager.GetActiveConfigRef(workflowName)
+		if err != nil {
+			workflow.GetLogger(ctx).Error("Failed to resolve active config", "name", workflowName, "error", err)
+			return manager_workflow.ConfigRef{}
+		}
+		return ref
+	}).Get(&ref)
+	if err != nil {
+		return ref, err
+	}
+	if ref.ID == uuid.Nil {
+		return ref, manager_workflow.ErrConfigNotFound
+	}
+	return ref, nil
 }
@@ -1,16 +1,18 @@
 package workflow
 
 import (
-	"context"
 	"github.com/aimustaev/service-workflow/internal/manager_workflow"
-	"time"
 
+	activity2 "go.temporal.io/sdk/activity"
 	"go.temporal.io/sdk/client"
 	"go.temporal.io/sdk/worker"
 	workflow2 "go.temporal.io/sdk/workflow"
 
 	act "github.com/aimustaev/service-workflow/internal/activity"
+	"github.com/aimustaev/service-workflow/internal/engine"
 	"github.com/aimustaev/service-workflow/internal/generated/proto"
+	"github.com/aimustaev/service-workflow/internal/plugin"
+	"github.com/aimustaev/service-workflow/internal/usertask"
 )
 
 type Workflow struct {
@@ -26,27 +28,27 @@ func NewWorkflow(activity *act.Activity, configManager *manager_workflow.ConfigM
 }
 
 // RegisterWorkflows registers all workflows with the worker
-func RegisterWorkflows(w worker.Worker, ticketClient proto.TicketServiceClient, temporalClient client.Client, configRepo manager_workflow.ConfigVersionRepository) {
+func RegisterWorkflows(w worker.Worker, ticketClient proto.TicketServiceClient, temporalClient client.Client, configManager *manager_workflow.ConfigManager, calendarRepo manager_workflow.CalendarRepository, taskRepo usertask.Repository, providers plugin.Providers) error {
 	activity := act.NewActivity(ticketClient)
 
-	// Создаем менеджер конфигураций с интервалом обновления 1 минута
-	configManager := manager_workflow.NewConfigManager(configRepo, time.Minute)
-	configManager.Start(context.Background())
+	// Реестр activity - единый источник для движка и регистрации в воркере
+	registry := act.NewTicketRegistry(activity)
+	usertask.RegisterActivities(registry, taskRepo)
+	if err := providers.Register(registry); err != nil {
+		return err
+	}
 
 	workflow := NewWorkflow(activity, configManager)
-	dynamicWorkflow := NewDynamicWorkflow(activity, temporalClient, configManager)
+	calendarManager := manager_workflow.NewCalendarManager(calendarRepo)
+	dynamicWorkflow := NewDynamicWorkflow(activity, temporalClient, configManager, registry, calendarManager)
 
 	// Register workflows
-	w.RegisterWorkflowWithOptions(dynamicWorkflow.Execute, workflow2.RegisterOptions{Name: "DynamicTicketWorkflow"})
+	w.RegisterWorkflowWithOptions(dynamicWorkflow.Execute, workflow2.RegisterOptions{Name: engine.DynamicWorkflowType})
 	w.RegisterWorkflow(workflow.SelectorWorkflow)
 
 	// Register activities
-	w.RegisterActivity(workflow.activity.CreateTicketActivity)
-	w.RegisterActivity(workflow.activity.ProcessMessageActivity)
-	w.RegisterActivity(workflow.activity.WaitActivity)
-	w.RegisterActivity(workflow.activity.ClassifierAcitivity)
-	w.RegisterActivity(workflow.activity.GetOrCreateTicketActivity)
-	w.RegisterActivity(workflow.activity.GetTicketByUserActivity)
-	w.RegisterActivity(workflow.activity.AddMassageToTicketActivity)
-	w.RegisterActivity(workflow.activity.SolveTicketAcitivity)
+	for _, def := range registry.List() {
+		w.RegisterActivityWithOptions(def.Fn, activity2.RegisterOptions{Name: def.Name})
+	}
+	return nil
 }